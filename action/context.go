@@ -31,9 +31,33 @@ type Context interface {
 	Logger() Logger
 }
 
+// RequestContext is implemented by Contexts which provide the webhook request
+// sent by Rasa, such as the Context passed to handlers by the Server.
+type RequestContext interface {
+	Context
+
+	// Request returns the webhook request sent by Rasa, which holds the
+	// tracker and domain among others.
+	Request() *Request
+}
+
+// RequestFrom returns the webhook request of the Context, and whether the
+// Context provides one. See RequestContext.
+func RequestFrom(ctx Context) (*Request, bool) {
+	if c, ok := ctx.(RequestContext); ok {
+		if req := c.Request(); req != nil {
+			return req, true
+		}
+	}
+	return nil, false
+}
+
 // contextImpl implements the Context interface for the
 type contextImpl struct {
 
+	//
+	request *Request
+
 	//
 	tracker *rasa.Tracker
 
@@ -47,7 +71,7 @@ type contextImpl struct {
 
 // ensure interfaces.
 var _ Logger = (*contextImpl)(nil)
-var _ Context = (*contextImpl)(nil)
+var _ RequestContext = (*contextImpl)(nil)
 
 // Logger implements Context.
 //
@@ -62,6 +86,11 @@ func (c *contextImpl) Context() context.Context {
 	return c.context
 }
 
+// Request implements RequestContext.
+func (c *contextImpl) Request() *Request {
+	return c.request
+}
+
 // Tracker implements Context.
 func (c *contextImpl) Tracker() *rasa.Tracker {
 	return c.tracker
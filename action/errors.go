@@ -7,8 +7,11 @@
 package action
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"runtime/debug"
+	"time"
 )
 
 // respErr
@@ -17,6 +20,11 @@ type respErr interface {
 	respBody() string
 }
 
+// actionErr is implemented by errors which occurred for a specific action.
+type actionErr interface {
+	actionName() string
+}
+
 // InvalidRequestError indicates that the request errored due to it being
 // malformed or otherwise incorrect (such as invalid JSON).
 type InvalidRequestError struct {
@@ -53,6 +61,7 @@ type MissingHandlerError struct {
 
 var _ error = (*MissingHandlerError)(nil)
 var _ respErr = (*MissingHandlerError)(nil)
+var _ actionErr = (*MissingHandlerError)(nil)
 
 // Error implements builtin.error.
 func (e *MissingHandlerError) Error() string {
@@ -72,6 +81,11 @@ func (e *MissingHandlerError) respBody() string {
 	return "invalid request"
 }
 
+// actionName implements actionErr.
+func (e *MissingHandlerError) actionName() string {
+	return e.Action
+}
+
 // HandlerError is the type used to wrap errors occuring inside action handlers.
 type HandlerError struct {
 	Action string
@@ -80,6 +94,7 @@ type HandlerError struct {
 
 var _ error = (*HandlerError)(nil)
 var _ respErr = (*HandlerError)(nil)
+var _ actionErr = (*HandlerError)(nil)
 
 // Error implements builtin.error.
 func (e *HandlerError) Error() string {
@@ -105,6 +120,184 @@ func (e *HandlerError) respBody() string {
 	return "error handling the action"
 }
 
+// actionName implements actionErr.
+func (e *HandlerError) actionName() string {
+	return e.Action
+}
+
+// HandlerPanicError is the type used to wrap panics occuring inside action
+// handlers, which are recovered by the Server.
+type HandlerPanicError struct {
+	Action string
+
+	// Value holds the value passed to panic.
+	Value interface{}
+
+	// Stack holds the formatted stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// newHandlerPanicError returns a HandlerPanicError for the recovered value,
+// with the stack trace of the current goroutine. It should be called from the
+// deferred function which recovered the panic.
+func newHandlerPanicError(action string, value interface{}) *HandlerPanicError {
+	return &HandlerPanicError{
+		Action: action,
+		Value:  value,
+		Stack:  debug.Stack(),
+	}
+}
+
+var _ error = (*HandlerPanicError)(nil)
+var _ respErr = (*HandlerPanicError)(nil)
+var _ actionErr = (*HandlerPanicError)(nil)
+
+// Error implements builtin.error.
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf(
+		"panic occured when handling action [%s]: %v",
+		e.Action,
+		e.Value,
+	)
+}
+
+// Unwrap implements errors.Unwrap.
+//
+// Unwrap returns the panic value if it is an error, and nil otherwise.
+func (e *HandlerPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+//
+func (e *HandlerPanicError) respCode() int {
+	return http.StatusInternalServerError
+}
+
+//
+func (e *HandlerPanicError) respBody() string {
+	return "error handling the action"
+}
+
+// actionName implements actionErr.
+func (e *HandlerPanicError) actionName() string {
+	return e.Action
+}
+
+// TimeoutError indicates that an action handler did not respond before its
+// timeout.
+type TimeoutError struct {
+	Action  string
+	Timeout time.Duration
+}
+
+var _ error = (*TimeoutError)(nil)
+var _ respErr = (*TimeoutError)(nil)
+var _ actionErr = (*TimeoutError)(nil)
+
+// Error implements builtin.error.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf(
+		"handling action [%s] timed out after %s",
+		e.Action,
+		e.Timeout,
+	)
+}
+
+// Unwrap implements errors.Unwrap.
+//
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+//
+func (e *TimeoutError) respCode() int {
+	return http.StatusGatewayTimeout
+}
+
+//
+func (e *TimeoutError) respBody() string {
+	return "timeout handling the action"
+}
+
+// actionName implements actionErr.
+func (e *TimeoutError) actionName() string {
+	return e.Action
+}
+
+// PartialResponseError can be returned by action handlers which were
+// interrupted, such as by the deadline of their context, to respond with the
+// events and messages collected so far in stead of an error. The Server logs
+// the error as a warning.
+//
+//	select {
+//	case result := <-results:
+//		// ...
+//	case <-ctx.Context().Done():
+//		dispatcher.Utter(&rasa.Message{Text: "This takes longer than expected."})
+//		return events, &action.PartialResponseError{Cause: ctx.Context().Err()}
+//	}
+type PartialResponseError struct {
+	Cause error
+}
+
+var _ error = (*PartialResponseError)(nil)
+
+// Error implements builtin.error.
+func (e *PartialResponseError) Error() string {
+	if e.Cause == nil {
+		return "partial response"
+	}
+	return fmt.Sprintf("partial response: %s", e.Cause.Error())
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *PartialResponseError) Unwrap() error {
+	return e.Cause
+}
+
+// SlotValidationError indicates that an action handler returned a SlotSet
+// event with a value which is invalid according to the domain. It is only
+// returned if the Server is in strict slots mode.
+type SlotValidationError struct {
+	Action string
+	Cause  error
+}
+
+var _ error = (*SlotValidationError)(nil)
+var _ respErr = (*SlotValidationError)(nil)
+var _ actionErr = (*SlotValidationError)(nil)
+
+// Error implements builtin.error.
+func (e *SlotValidationError) Error() string {
+	return fmt.Sprintf(
+		"action [%s] returned an invalid slot value: %s",
+		e.Action,
+		e.Cause.Error(),
+	)
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *SlotValidationError) Unwrap() error {
+	return e.Cause
+}
+
+//
+func (e *SlotValidationError) respCode() int {
+	return http.StatusInternalServerError
+}
+
+//
+func (e *SlotValidationError) respBody() string {
+	return "error handling the action"
+}
+
+// actionName implements actionErr.
+func (e *SlotValidationError) actionName() string {
+	return e.Action
+}
+
 // UnmarshalError indicates an error resulting from unmarshalling invalid JSON.
 type UnmarshalError struct {
 	cause error
@@ -136,11 +329,21 @@ func (e *UnmarshalError) respBody() string {
 
 // ExecutionRejection implements error for errors that should stop Rasa from
 // executing an action.
+//
+// When a handler returns an ExecutionRejection, possibly wrapped, the Server
+// responds with status 400 and the action name, which makes Rasa log an
+// ActionExecutionRejected event and continue with the next policy, such as
+// when a form rejects the user input. Action defaults to the name of the
+// executed action.
 type ExecutionRejection struct {
 	Action string
 	Reason string
 }
 
+var _ error = (*ExecutionRejection)(nil)
+var _ respErr = (*ExecutionRejection)(nil)
+var _ actionErr = (*ExecutionRejection)(nil)
+
 // Error implements builtin.error.
 func (e *ExecutionRejection) Error() string {
 	return fmt.Sprintf(
@@ -149,3 +352,23 @@ func (e *ExecutionRejection) Error() string {
 		e.Reason,
 	)
 }
+
+// respCode implements respErr.
+func (e *ExecutionRejection) respCode() int {
+	return http.StatusBadRequest
+}
+
+// respBody implements respErr.
+//
+// respBody returns the reason, or the default message of Rasa's Python SDK.
+func (e *ExecutionRejection) respBody() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("Custom action '%s' rejected execution.", e.Action)
+}
+
+// actionName implements actionErr.
+func (e *ExecutionRejection) actionName() string {
+	return e.Action
+}
@@ -15,7 +15,7 @@ import (
 
 const (
 	// RequestedSlot is used to store information needed to do the form handling.
-	RequestedSlot = "requested_slot"
+	RequestedSlot = rasa.SlotRequested
 
 	// LoopInterruptedKey is used to detect if the loop is interrupted
 	LoopInterruptedKey = "is_interrupted"
@@ -0,0 +1,58 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package form
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+	"go.scarlet.dev/rasa/action"
+)
+
+// testValidator validates the city slot, and requests the date slot.
+type testValidator struct {
+	ValidatorEmbed
+}
+
+func (testValidator) Form() string { return "travel_form" }
+
+func (testValidator) Validator(slot string) SlotValidator { return DefaultValidator(slot) }
+
+func (v testValidator) Validate(ctx *ValidatorContext, disp *action.CollectingDispatcher) (rasa.Events, error) {
+	events, err := ctx.ValidateSlots(disp, rasa.Slots{"city": "Paris"})
+	if err != nil {
+		return nil, err
+	}
+	return append(events, ctx.RequestSlot("date")), nil
+}
+
+// TestValidatorActionStrictSlots
+func TestValidatorActionStrictSlots(t *testing.T) {
+	server := action.NewServer(&ValidatorAction{Validator: testValidator{}})
+	server.StrictSlots = true
+	server.Domain = &rasa.Domain{Slots: map[string]rasa.SlotDescription{
+		"city": {Type: "text"},
+		"date": {Type: "text"},
+	}}
+
+	body := `{"next_action": "validate_travel_form", "sender_id": "test"}`
+	req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{
+		"events": [
+			{"event": "slot", "name": "city", "value": "Paris"},
+			{"event": "slot", "name": "requested_slot", "value": "date"}
+		],
+		"responses": []
+	}`, w.Body.String())
+}
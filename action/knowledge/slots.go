@@ -6,6 +6,8 @@
 
 package knowledge
 
+import "go.scarlet.dev/rasa"
+
 // Slots TODO
 type Slots struct {
 	KBAttribute      string   `json:"attribute,omitempty"`
@@ -19,9 +21,9 @@ type Slots struct {
 //
 const (
 	SlotAttribute      = "attribute"
-	SlotLastObject     = "knowledge_base_last_object"
-	SlotLastObjectType = "knowledge_base_last_object_type"
-	SlotListedObjects  = "knowledge_base_listed_objects"
+	SlotLastObject     = rasa.SlotKnowledgeBaseLastObject
+	SlotLastObjectType = rasa.SlotKnowledgeBaseLastObjectType
+	SlotListedObjects  = rasa.SlotKnowledgeBaseListedObjects
 	SlotMention        = "mention"
 	SlotObjectType     = "object_type"
 )
@@ -55,7 +55,7 @@ func resolveMention(ctx *Context, omapper OrdinalMapper) (value string, resolved
 	var mention string
 	mentionOK := ctx.Tracker().SlotAs(SlotMention, &mention)
 	var listedItems []string
-	listedItemsOK := ctx.Tracker().SlotAs(SlotListedObjects, listedItems)
+	listedItemsOK := ctx.Tracker().SlotAs(SlotListedObjects, &listedItems)
 	var lastObj string
 	lastObjOK := ctx.Tracker().SlotAs(SlotLastObject, &lastObj)
 	lastObjType, _ := ctx.Tracker().Slot(SlotLastObjectType)
@@ -0,0 +1,261 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error types of the errors_total metric.
+const (
+	MetricsErrorUnmarshal      = "unmarshal"
+	MetricsErrorMissingHandler = "missing_handler"
+	MetricsErrorHandler        = "handler"
+	MetricsErrorRejection      = "rejection"
+	MetricsErrorPanic          = "panic"
+	MetricsErrorTimeout        = "timeout"
+	MetricsErrorSlotValidation = "slot_validation"
+	MetricsErrorOther          = "other"
+)
+
+// DefaultBuckets holds the default upper bounds of the buckets of the latency
+// histogram in seconds, which are the default buckets of Prometheus.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects metrics of the webhook requests handled by a Server, and
+// serves them in the Prometheus text exposition format. The metrics are
+// labelled by action, with an empty action for requests which could not be
+// decoded or for which no handler is registered, which keeps the number of
+// series bounded by the registered handlers:
+//
+//	rasa_action_requests_total        counter of the requests
+//	rasa_action_errors_total          counter of the errors, also labelled by type
+//	rasa_action_duration_seconds      histogram of the request latency
+//	rasa_action_requests_in_flight    gauge of the requests being handled
+//
+// Metrics implements http.Handler, so that it can also be served separately
+// from the Server. The methods of Metrics are safe for concurrent use.
+type Metrics struct {
+	buckets []float64
+
+	mu      sync.Mutex
+	actions map[string]*actionMetrics
+}
+
+// actionMetrics holds the metrics of a single action.
+type actionMetrics struct {
+	requests uint64
+	inFlight int64
+	errors   map[string]uint64
+
+	// counts holds the count of observations per bucket, with a final bucket
+	// for +Inf.
+	counts []uint64
+	sum    float64
+}
+
+// ensure interface
+var _ http.Handler = (*Metrics)(nil)
+
+// NewMetrics creates a new Metrics instance with the provided upper bounds of
+// the buckets of the latency histogram in seconds, or DefaultBuckets if none
+// are provided.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		buckets: buckets,
+		actions: make(map[string]*actionMetrics),
+	}
+}
+
+// action returns the metrics of the action. It must be called with m.mu held.
+func (m *Metrics) action(name string) *actionMetrics {
+	a, exists := m.actions[name]
+	if !exists {
+		a = &actionMetrics{
+			errors: make(map[string]uint64),
+			counts: make([]uint64, len(m.buckets)+1),
+		}
+		m.actions[name] = a
+	}
+	return a
+}
+
+// begin records the start of the handling of a request for the action, and
+// returns a function to record its end. begin is a no-op if m is nil.
+func (m *Metrics) begin(action string) (end func()) {
+	if m == nil {
+		return func() {}
+	}
+
+	m.mu.Lock()
+	m.action(action).inFlight++
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		m.action(action).inFlight--
+		m.mu.Unlock()
+	}
+}
+
+// observe records a handled request for the action with its duration, and
+// the error if any. observe is a no-op if m is nil.
+func (m *Metrics) observe(action string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	seconds := duration.Seconds()
+	bucket := sort.SearchFloat64s(m.buckets, seconds) // first bound >= seconds
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.action(action)
+	a.requests++
+	a.counts[bucket]++
+	a.sum += seconds
+	if err != nil {
+		a.errors[metricsErrorType(err)]++
+	}
+}
+
+// metricsErrorType returns the error type of the error for the errors_total
+// metric.
+func metricsErrorType(err error) string {
+	switch err.(type) {
+	case *UnmarshalError, *InvalidRequestError:
+		return MetricsErrorUnmarshal
+	case *MissingHandlerError:
+		return MetricsErrorMissingHandler
+	case *HandlerError:
+		return MetricsErrorHandler
+	case *ExecutionRejection:
+		return MetricsErrorRejection
+	case *HandlerPanicError:
+		return MetricsErrorPanic
+	case *TimeoutError:
+		return MetricsErrorTimeout
+	case *SlotValidationError:
+		return MetricsErrorSlotValidation
+	}
+	return MetricsErrorOther
+}
+
+// ServeHTTP implements http.Handler.
+//
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = m.WriteText(w)
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format to w.
+// The actions are sorted by name.
+func (m *Metrics) WriteText(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.actions))
+	for name := range m.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := bufio.NewWriter(w)
+	writeMetricHeader(b, "rasa_action_requests_total", "counter", "Number of webhook requests handled, by action.")
+	for _, name := range names {
+		writeMetric(b, "rasa_action_requests_total", labels("action", name), formatUint(m.actions[name].requests))
+	}
+
+	writeMetricHeader(b, "rasa_action_errors_total", "counter", "Number of webhook requests which failed, by action and error type.")
+	for _, name := range names {
+		a := m.actions[name]
+		types := make([]string, 0, len(a.errors))
+		for typ := range a.errors {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		for _, typ := range types {
+			writeMetric(b, "rasa_action_errors_total", labels("action", name, "type", typ), formatUint(a.errors[typ]))
+		}
+	}
+
+	writeMetricHeader(b, "rasa_action_duration_seconds", "histogram", "Latency of webhook requests in seconds, by action.")
+	for _, name := range names {
+		a := m.actions[name]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += a.counts[i]
+			writeMetric(b, "rasa_action_duration_seconds_bucket", labels("action", name, "le", formatFloat(bound)), formatUint(cumulative))
+		}
+		cumulative += a.counts[len(m.buckets)]
+		writeMetric(b, "rasa_action_duration_seconds_bucket", labels("action", name, "le", "+Inf"), formatUint(cumulative))
+		writeMetric(b, "rasa_action_duration_seconds_sum", labels("action", name), formatFloat(a.sum))
+		writeMetric(b, "rasa_action_duration_seconds_count", labels("action", name), formatUint(a.requests))
+	}
+
+	writeMetricHeader(b, "rasa_action_requests_in_flight", "gauge", "Number of webhook requests being handled, by action.")
+	for _, name := range names {
+		writeMetric(b, "rasa_action_requests_in_flight", labels("action", name), strconv.FormatInt(m.actions[name].inFlight, 10))
+	}
+	return b.Flush()
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric.
+func writeMetricHeader(w *bufio.Writer, name, typ, help string) {
+	_, _ = w.WriteString("# HELP " + name + " " + help + "\n")
+	_, _ = w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// writeMetric writes a sample of a metric.
+func writeMetric(w *bufio.Writer, name, labels, value string) {
+	_, _ = w.WriteString(name + labels + " " + value + "\n")
+}
+
+// labels formats the label pairs, provided as alternating names and values.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes label values according to the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatUint formats a counter value.
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+// formatFloat formats a sample value or bucket bound.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetricsWriteText
+func TestMetricsWriteText(t *testing.T) {
+	m := NewMetrics(0.5, 0.1)
+	m.observe("action_a", 50*time.Millisecond, nil)
+	m.observe("action_a", 100*time.Millisecond, &HandlerError{"action_a", errors.New("test")})
+	m.observe("action_a", time.Second, &TimeoutError{"action_a", time.Second})
+	m.observe("", 0, &UnmarshalError{errors.New("test")})
+	m.observe("action_\"b\"", 200*time.Millisecond, &ExecutionRejection{Action: "action_\"b\""})
+	end := m.begin("action_a")
+	m.begin("action_a")()
+
+	var b strings.Builder
+	require.NoError(t, m.WriteText(&b))
+	require.Equal(t, `# HELP rasa_action_requests_total Number of webhook requests handled, by action.
+# TYPE rasa_action_requests_total counter
+rasa_action_requests_total{action=""} 1
+rasa_action_requests_total{action="action_\"b\""} 1
+rasa_action_requests_total{action="action_a"} 3
+# HELP rasa_action_errors_total Number of webhook requests which failed, by action and error type.
+# TYPE rasa_action_errors_total counter
+rasa_action_errors_total{action="",type="unmarshal"} 1
+rasa_action_errors_total{action="action_\"b\"",type="rejection"} 1
+rasa_action_errors_total{action="action_a",type="handler"} 1
+rasa_action_errors_total{action="action_a",type="timeout"} 1
+# HELP rasa_action_duration_seconds Latency of webhook requests in seconds, by action.
+# TYPE rasa_action_duration_seconds histogram
+rasa_action_duration_seconds_bucket{action="",le="0.1"} 1
+rasa_action_duration_seconds_bucket{action="",le="0.5"} 1
+rasa_action_duration_seconds_bucket{action="",le="+Inf"} 1
+rasa_action_duration_seconds_sum{action=""} 0
+rasa_action_duration_seconds_count{action=""} 1
+rasa_action_duration_seconds_bucket{action="action_\"b\"",le="0.1"} 0
+rasa_action_duration_seconds_bucket{action="action_\"b\"",le="0.5"} 1
+rasa_action_duration_seconds_bucket{action="action_\"b\"",le="+Inf"} 1
+rasa_action_duration_seconds_sum{action="action_\"b\""} 0.2
+rasa_action_duration_seconds_count{action="action_\"b\""} 1
+rasa_action_duration_seconds_bucket{action="action_a",le="0.1"} 2
+rasa_action_duration_seconds_bucket{action="action_a",le="0.5"} 2
+rasa_action_duration_seconds_bucket{action="action_a",le="+Inf"} 3
+rasa_action_duration_seconds_sum{action="action_a"} 1.15
+rasa_action_duration_seconds_count{action="action_a"} 3
+# HELP rasa_action_requests_in_flight Number of webhook requests being handled, by action.
+# TYPE rasa_action_requests_in_flight gauge
+rasa_action_requests_in_flight{action=""} 0
+rasa_action_requests_in_flight{action="action_\"b\""} 0
+rasa_action_requests_in_flight{action="action_a"} 1
+`, b.String())
+
+	end()
+	b.Reset()
+	require.NoError(t, m.WriteText(&b))
+	require.Contains(t, b.String(), "rasa_action_requests_in_flight{action=\"action_a\"} 0\n")
+}
+
+// TestServerMetrics
+func TestServerMetrics(t *testing.T) {
+	s := NewServer(&testHandler1{}, &testHandlerErr{}, &testHandlerReject{}, &testHandlerPanic{})
+
+	// the endpoint is only served with metrics
+	req := httptest.NewRequest("GET", "https://example.com/metrics", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	s.Metrics = NewMetrics()
+	for _, action := range []string{"action_test", "action_test", "action_error", "action_reject", "action_panic", "action_missing", "action_other"} {
+		serveWebhook(s, action)
+	}
+	req = httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte("{")))
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "https://example.com/metrics", nil)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`rasa_action_requests_total{action="action_test"} 2`,
+		`rasa_action_requests_total{action=""} 3`,
+		`rasa_action_errors_total{action="",type="missing_handler"} 2`,
+		`rasa_action_errors_total{action="",type="unmarshal"} 1`,
+		`rasa_action_errors_total{action="action_error",type="handler"} 1`,
+		`rasa_action_errors_total{action="action_panic",type="panic"} 1`,
+		`rasa_action_errors_total{action="action_reject",type="rejection"} 1`,
+		`rasa_action_duration_seconds_count{action="action_test"} 2`,
+		`rasa_action_duration_seconds_bucket{action="action_test",le="+Inf"} 2`,
+		`rasa_action_requests_in_flight{action="action_test"} 0`,
+	} {
+		require.Contains(t, body, line+"\n")
+	}
+	require.NotContains(t, body, `rasa_action_errors_total{action="action_test"`)
+	require.NotContains(t, body, `action_missing`)
+	require.NotContains(t, body, `action_other`)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"time"
+
+	"go.scarlet.dev/rasa"
+)
+
+// Middleware wraps a Handler to add behaviour around its execution, such as
+// logging or authorization checks. The returned Handler should report the
+// same action name as next.
+//
+// A Middleware can inspect the webhook request through Context.Request, the
+// responses collected by the dispatcher, and the events returned by next.
+type Middleware func(next Handler) Handler
+
+// RunFunc is the signature of Handler.Run.
+type RunFunc func(ctx Context, dispatcher *CollectingDispatcher) (rasa.Events, error)
+
+// WrapHandler returns a Handler for the action of h, which runs fn instead of
+// h.Run. It is a convenience for implementing a Middleware:
+//
+//	func(next action.Handler) action.Handler {
+//		return action.WrapHandler(next, func(ctx action.Context, d *action.CollectingDispatcher) (rasa.Events, error) {
+//			// ...
+//			return next.Run(ctx, d)
+//		})
+//	}
+func WrapHandler(h Handler, fn RunFunc) Handler {
+	return &wrappedHandler{name: h.ActionName(), run: fn}
+}
+
+// wrappedHandler implements Handler for WrapHandler.
+type wrappedHandler struct {
+	name string
+	run  RunFunc
+}
+
+// ensure interface
+var _ Handler = (*wrappedHandler)(nil)
+
+// ActionName implements Handler.
+func (h *wrappedHandler) ActionName() string {
+	return h.name
+}
+
+// Run implements Handler.
+func (h *wrappedHandler) Run(ctx Context, dispatcher *CollectingDispatcher) (rasa.Events, error) {
+	return h.run(ctx, dispatcher)
+}
+
+// Chain returns a Middleware which applies the provided middlewares in
+// order, so that the first middleware is the outermost.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Use adds the middlewares to the server, which wrap every handler executed
+// by the server. Middlewares are applied in the order in which they are
+// added, so that the first middleware is the outermost.
+//
+// This method should only be called *before* the server is started.
+func (s *Server) Use(middlewares ...Middleware) *Server {
+	s.Middlewares = append(s.Middlewares, middlewares...)
+	return s
+}
+
+// wrapHandler applies the middlewares of the server to the handler.
+func (s *Server) wrapHandler(handler Handler) Handler {
+	if len(s.Middlewares) == 0 {
+		return handler
+	}
+	return Chain(s.Middlewares...)(handler)
+}
+
+// Recover returns a Middleware which recovers panics in the handler, and
+// returns them as a HandlerPanicError in stead. The Server recovers panics
+// itself, but Recover allows the middlewares added before it to observe the
+// error, such as Timing and RequestLogging.
+//
+// The Server logs the HandlerPanicError and passes it to Server.OnPanic as if
+// it recovered the panic itself.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return WrapHandler(next, func(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					events, err = nil, newHandlerPanicError(next.ActionName(), v)
+				}
+			}()
+			return next.Run(ctx, dispatcher)
+		})
+	}
+}
+
+// Timing returns a Middleware which measures the duration of every handler
+// execution, and passes it to observe. If observe is nil, the duration is
+// logged at debug level to the logger of the Context.
+func Timing(observe func(ctx Context, action string, duration time.Duration, err error)) Middleware {
+	if observe == nil {
+		observe = func(ctx Context, action string, duration time.Duration, err error) {
+			ctx.Logger().Debugf("action [%s] took %s", action, duration)
+		}
+	}
+
+	return func(next Handler) Handler {
+		return WrapHandler(next, func(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+			start := time.Now()
+			defer func() {
+				observe(ctx, next.ActionName(), time.Since(start), err)
+			}()
+			return next.Run(ctx, dispatcher)
+		})
+	}
+}
+
+// RequestLogging returns a Middleware which logs a single line in key=value
+// format for every handler execution, to the logger of the Context. The line
+// holds the action, the sender, the number of returned events and responses,
+// the duration, and the error if any. Failed executions are logged at error
+// level, others at info level.
+func RequestLogging() Middleware {
+	return func(next Handler) Handler {
+		return WrapHandler(next, func(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+			start := time.Now()
+			events, err = next.Run(ctx, dispatcher)
+
+			responses, sender, version := 0, "", ""
+			if dispatcher != nil {
+				responses = len(*dispatcher)
+			}
+			if req, ok := RequestFrom(ctx); ok {
+				sender, version = req.SenderID, req.Version
+			}
+			const format = "action=%q sender=%q version=%q events=%d responses=%d duration=%s"
+			args := []interface{}{
+				next.ActionName(), sender, version, len(events), responses, time.Since(start),
+			}
+
+			if err != nil {
+				ctx.Logger().Errorf(format+" error=%q", append(args, err.Error())...)
+			} else {
+				ctx.Logger().Infof(format, args...)
+			}
+			return
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// testLogger implements Logger by recording the log lines.
+type testLogger struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) logf(level, format string, args ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) { l.logf("DEBUG", format, args...) }
+func (l *testLogger) Infof(format string, args ...interface{})  { l.logf("INFO", format, args...) }
+func (l *testLogger) Warnf(format string, args ...interface{})  { l.logf("WARN", format, args...) }
+func (l *testLogger) Errorf(format string, args ...interface{}) { l.logf("ERROR", format, args...) }
+
+// matching returns the lines with the provided prefix.
+func (l *testLogger) matching(prefix string) (lines []string) {
+	l.Lock()
+	defer l.Unlock()
+	for _, line := range l.lines {
+		if strings.HasPrefix(line, prefix) {
+			lines = append(lines, line)
+		}
+	}
+	return
+}
+
+type testHandlerPanic struct{}
+
+func (testHandlerPanic) ActionName() string { return "action_panic" }
+
+func (testHandlerPanic) Run(ctx Context, dispatcher *CollectingDispatcher) (rasa.Events, error) {
+	panic("test panic")
+}
+
+// serveWebhook sends a webhook request for the action to the server.
+func serveWebhook(s *Server, action string) *httptest.ResponseRecorder {
+	body := `{"next_action": "` + action + `", "sender_id": "sender", "version": "2.0.0"}`
+	req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+// TestServerUse
+func TestServerUse(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return WrapHandler(next, func(ctx Context, dispatcher *CollectingDispatcher) (rasa.Events, error) {
+				req, ok := RequestFrom(ctx)
+				require.True(t, ok)
+				require.Equal(t, "sender", req.SenderID)
+				calls = append(calls, name+" before")
+				events, err := next.Run(ctx, dispatcher)
+				calls = append(calls, fmt.Sprintf("%s after %d %d", name, len(events), len(*dispatcher)))
+				return events, err
+			})
+		}
+	}
+	appendEvent := func(next Handler) Handler {
+		return WrapHandler(next, func(ctx Context, dispatcher *CollectingDispatcher) (rasa.Events, error) {
+			events, err := next.Run(ctx, dispatcher)
+			return append(events, &rasa.SlotSet{Key: "audited", Value: true}), err
+		})
+	}
+
+	s := NewServer(&testHandler1{}).Use(trace("outer"), trace("inner")).Use(appendEvent)
+	w := serveWebhook(s, "action_test")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{
+		"events": [
+			{"event": "slot", "name": "test", "value": "420"},
+			{"event": "slot", "name": "audited", "value": true}
+		],
+		"responses": [{"text": "test string"}]
+	}`, w.Body.String())
+	require.Equal(t, []string{
+		"outer before",
+		"inner before",
+		"inner after 2 1",
+		"outer after 2 1",
+	}, calls)
+
+	// the middlewares do not affect the registered handlers
+	require.IsType(t, &testHandler1{}, s.Handlers["action_test"])
+}
+
+// TestMiddlewares
+func TestMiddlewares(t *testing.T) {
+	t.Run("recover", func(t *testing.T) {
+		logger := &testLogger{}
+		var observed error
+		timing := Timing(func(ctx Context, action string, duration time.Duration, err error) {
+			observed = err
+		})
+		s := NewServer(&testHandlerPanic{}, &testHandler1{}).Use(timing, Recover())
+		s.Logger = logger
+
+		w := serveWebhook(s, "action_panic")
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.JSONEq(t, `{"action_name":"action_panic","error":"error handling the action"}`, w.Body.String())
+		require.IsType(t, &HandlerPanicError{}, observed)
+
+		// the panic is logged with its stack trace
+		lines := logger.matching("ERROR panic occured when handling action [action_panic]: test panic\n")
+		require.Len(t, lines, 1)
+		require.Contains(t, lines[0], "testHandlerPanic.Run")
+
+		w = serveWebhook(s, "action_test")
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("timing", func(t *testing.T) {
+		var observed []string
+		s := NewServer(&testHandler1{}, &testHandlerErr{}).Use(Timing(
+			func(ctx Context, action string, duration time.Duration, err error) {
+				require.True(t, duration >= 0)
+				observed = append(observed, fmt.Sprintf("%s %v", action, err))
+			},
+		))
+		serveWebhook(s, "action_test")
+		serveWebhook(s, "action_error")
+		require.Equal(t, []string{"action_test <nil>", "action_error test error"}, observed)
+
+		// log by default
+		logger := &testLogger{}
+		s = NewServer(&testHandler1{}).Use(Timing(nil))
+		s.Logger = logger
+		serveWebhook(s, "action_test")
+		require.Len(t, logger.matching("DEBUG action [action_test] took "), 1)
+	})
+
+	t.Run("request logging", func(t *testing.T) {
+		logger := &testLogger{}
+		s := NewServer(&testHandler1{}, &testHandlerErr{}).Use(RequestLogging())
+		s.Logger = logger
+
+		serveWebhook(s, "action_test")
+		lines := logger.matching("INFO action=")
+		require.Len(t, lines, 1)
+		require.Contains(t, lines[0], `action="action_test" sender="sender" version="2.0.0" events=1 responses=1 duration=`)
+
+		serveWebhook(s, "action_error")
+		lines = logger.matching("ERROR action=")
+		require.Len(t, lines, 1)
+		require.Contains(t, lines[0], `action="action_error" sender="sender"`)
+		require.Contains(t, lines[0], `error="test error"`)
+	})
+}
+
+// testContext implements Context without providing the request, as contexts
+// implemented outside of the package do.
+type testContext struct{ baseContext }
+
+// baseContext allows embedding a Context, whose field would otherwise
+// conflict with its Context method.
+type baseContext = Context
+
+// TestRequestFrom
+func TestRequestFrom(t *testing.T) {
+	req := &Request{SenderID: "sender"}
+	cases := []struct {
+		ctx    Context
+		expect *Request
+	}{
+		{ctx: &contextImpl{request: req}, expect: req},
+		{ctx: &contextImpl{}},
+		{ctx: testContext{&contextImpl{request: req}}},
+	}
+
+	for i := range cases {
+		result, ok := RequestFrom(cases[i].ctx)
+		require.Equalf(t, cases[i].expect != nil, ok, "failed on %d", i)
+		require.Equalf(t, cases[i].expect, result, "failed on %d", i)
+	}
+}
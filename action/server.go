@@ -10,11 +10,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strings"
 	"time"
 
+	perrors "github.com/pkg/errors"
 	"go.scarlet.dev/errors"
 	"go.scarlet.dev/rasa"
 )
@@ -40,6 +42,42 @@ type Server struct {
 	Handlers   map[string]Handler
 	PrettyJSON bool
 	Logger     Logger
+
+	// LazyEvents enables lazy decoding of the tracker events. The events in
+	// the Tracker passed to the handlers are *rasa.LazyEvent values, which
+	// are only decoded when accessed through rasa.Events.At or an iterator.
+	LazyEvents bool
+
+	// StrictSlots enables the validation of the SlotSet events returned by
+	// the handlers. Slot values are converted according to the domain, and a
+	// SlotValidationError is returned if a value is invalid.
+	//
+	// The values are validated against Domain, or against the domain sent by
+	// Rasa if Domain is nil. Validation is skipped if neither is available.
+	StrictSlots bool
+
+	// Timeout holds the maximum duration of the execution of a handler,
+	// which defaults to DefaultTimeout. A negative duration disables the
+	// timeout. Handlers can override the timeout by implementing
+	// TimeoutHandler.
+	Timeout time.Duration
+
+	// Domain optionally holds the domain of the assistant, such as loaded by
+	// rasa.LoadDomain.
+	Domain *rasa.Domain
+
+	// Middlewares holds the middlewares which wrap every handler, with the
+	// first middleware as the outermost. See Server.Use.
+	Middlewares []Middleware
+
+	// OnPanic is optionally called for every panic in a handler, such as to
+	// report it to an error tracker. The panic is recovered and logged with
+	// its stack trace by the server, and Rasa receives an error response.
+	OnPanic func(ctx Context, err *HandlerPanicError)
+
+	// Metrics optionally collects metrics of the webhook requests, which are
+	// served at the /metrics endpoint. See NewMetrics.
+	Metrics *Metrics
 }
 
 // ensure interface
@@ -96,6 +134,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.withLogs(s.handleHealth, w, r)
 	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/webhook"):
 		s.withLogs(s.handleWebhook, w, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/metrics") && s.Metrics != nil:
+		s.Metrics.ServeHTTP(w, r)
 	default:
 		http.NotFound(w, r) // TODO(ed): log this?
 	}
@@ -105,9 +145,17 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // action server.
 func (s *Server) handleWebhook(ctx context.Context, r *http.Request) (response interface{}, err error) {
 	// TODO
-	req := Request{}
+	// requests for unknown actions are recorded without an action, so that
+	// clients cannot create a series for every name they send
+	var metricsAction string
+	start := time.Now()
+	defer func() {
+		s.Metrics.observe(metricsAction, time.Since(start), err)
+	}()
+
 	defer r.Body.Close()
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := s.decodeRequest(r.Body)
+	if err != nil {
 		err = &UnmarshalError{cause: err}
 		return
 	}
@@ -121,25 +169,49 @@ func (s *Server) handleWebhook(ctx context.Context, r *http.Request) (response i
 		err = &MissingHandlerError{action}
 		return
 	}
+	metricsAction = action
+	defer s.Metrics.begin(action)()
 
 	// handle action
+	timeout := s.handlerTimeout(handler)
+	ctx, cancel := s.requestContext(ctx, timeout)
+	defer cancel()
+
 	disp := CollectingDispatcher{} // non-nil
-	events, err := handler.Run(
-		&contextImpl{
-			context: ctx,
-			logger:  s.Logger,
-			tracker: req.Tracker,
-			domain:  req.Domain,
-		},
-		&disp,
-	)
+	actionCtx := &contextImpl{
+		context: ctx,
+		logger:  s.Logger,
+		request: &req,
+		tracker: req.Tracker,
+		domain:  req.Domain,
+	}
+	events, err := s.runHandlerWithTimeout(actionCtx, s.wrapHandler(handler), &disp, timeout)
 	if err != nil {
-		err = &HandlerError{action, err}
-		return
+		var panicErr *HandlerPanicError
+		if perrors.As(err, &panicErr) {
+			// discard the partial results of the handler
+			s.reportPanic(actionCtx, panicErr)
+			err = panicErr
+			return
+		}
+
+		var partial *PartialResponseError
+		if perrors.As(err, &partial) {
+			// respond with the results collected so far
+			s.warnf("action [%s] responded partially: %s", action, partial.Error())
+			err = nil
+		} else {
+			err = s.handlerError(ctx, action, timeout, err)
+			return
+		}
 	}
 	if events == nil {
 		events = rasa.Events{} // non-nil
 	}
+	if events, err = s.validateSlots(&req, events); err != nil {
+		err = &SlotValidationError{action, err}
+		return
+	}
 
 	// respond
 	response = &Response{
@@ -149,6 +221,89 @@ func (s *Server) handleWebhook(ctx context.Context, r *http.Request) (response i
 	return
 }
 
+// handlerError returns the error which is returned for the error of the
+// handler of the action.
+func (s *Server) handlerError(ctx context.Context, action string, timeout time.Duration, err error) error {
+	var rejection *ExecutionRejection
+	var timeoutErr *TimeoutError
+	switch {
+	case perrors.As(err, &rejection):
+		// respond with Rasa's rejection protocol
+		rejected := *rejection
+		if rejected.Action == "" {
+			rejected.Action = action
+		}
+		return &rejected
+	case perrors.As(err, &timeoutErr):
+		return timeoutErr
+	case ctx.Err() == context.DeadlineExceeded && perrors.Is(err, context.DeadlineExceeded):
+		// the handler returned the error of its context
+		return &TimeoutError{Action: action, Timeout: timeout}
+	}
+	return &HandlerError{action, err}
+}
+
+// runHandler runs the handler, and recovers panics as a HandlerPanicError.
+func (s *Server) runHandler(
+	ctx Context,
+	handler Handler,
+	dispatcher *CollectingDispatcher,
+) (events rasa.Events, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			events, err = nil, newHandlerPanicError(handler.ActionName(), v)
+		}
+	}()
+	return handler.Run(ctx, dispatcher)
+}
+
+// reportPanic logs the panic with its stack trace, and passes it to
+// s.OnPanic.
+func (s *Server) reportPanic(ctx Context, err *HandlerPanicError) {
+	s.errorf("%s\n%s", err.Error(), err.Stack)
+	if s.OnPanic != nil {
+		s.OnPanic(ctx, err)
+	}
+}
+
+// decodeRequest decodes the webhook request. If s.LazyEvents is set, the
+// tracker events are decoded lazily.
+func (s *Server) decodeRequest(body io.Reader) (req Request, err error) {
+	if !s.LazyEvents {
+		err = json.NewDecoder(body).Decode(&req)
+		return
+	}
+
+	// the tracker field shadows the field of the embedded Request
+	var lazy struct {
+		Request
+		Tracker json.RawMessage `json:"tracker"`
+	}
+	if err = json.NewDecoder(body).Decode(&lazy); err != nil {
+		return
+	}
+
+	req = lazy.Request
+	if len(lazy.Tracker) > 0 && string(lazy.Tracker) != "null" {
+		req.Tracker = new(rasa.Tracker)
+		err = rasa.UnmarshalLazyTracker(lazy.Tracker, req.Tracker)
+	}
+	return
+}
+
+// validateSlots converts the slot values in the events according to the
+// domain, if s.StrictSlots is set.
+func (s *Server) validateSlots(req *Request, events rasa.Events) (rasa.Events, error) {
+	domain := s.Domain
+	if domain == nil {
+		domain = req.Domain
+	}
+	if !s.StrictSlots || domain == nil {
+		return events, nil
+	}
+	return domain.CoerceEvents(events)
+}
+
 // handleHealth implements the HTTP handler for the /health endpoint of the
 // action server.
 func (s *Server) handleHealth(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -179,22 +334,25 @@ func (s *Server) serveError(w http.ResponseWriter, errp *error) {
 		return
 	}
 
-	if e, ok := err.(respErr); ok {
-		_ = s.serveJSON(w, e.respCode(), struct {
-			Error string `json:"error"`
-		}{
-			Error: e.respBody(),
-		})
-		return
+	// include the action name, if known
+	var action string
+	var ae actionErr
+	if perrors.As(err, &ae) {
+		action = ae.actionName()
 	}
 
-	// generic error response
-	_ = s.serveJSON(w, http.StatusInternalServerError, struct {
+	status, body := http.StatusInternalServerError, err.Error()
+	var re respErr
+	if perrors.As(err, &re) {
+		status, body = re.respCode(), re.respBody()
+	}
+
+	_ = s.serveJSON(w, status, struct {
 		ActionName string `json:"action_name,omitempty"`
 		Error      string `json:"error"`
 	}{
-		// TODO include action_name in the response
-		Error: err.Error(),
+		ActionName: action,
+		Error:      body,
 	})
 }
 
@@ -216,7 +374,7 @@ func (s *Server) withLogs(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
-	ctx, cancel := s.requestContext(r.Context(), r)
+	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
 	// log
@@ -239,10 +397,13 @@ func (s *Server) withLogs(
 	err = s.serveJSON(w, http.StatusOK, resp)
 }
 
-//
-func (s *Server) requestContext(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
-	// TODO(ed): enhance rasactx.Request using r
-	return context.WithTimeout(ctx, time.Second*10) // TODO(ed): extract timeout constant
+// requestContext returns the context for the execution of a handler with the
+// provided timeout, which is negative for no timeout.
+func (s *Server) requestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // debugf
@@ -259,6 +420,13 @@ func (s *Server) infof(format string, args ...interface{}) {
 	}
 }
 
+// warnf
+func (s *Server) warnf(format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Warnf(format, args...)
+	}
+}
+
 // errorf
 func (s *Server) errorf(format string, args ...interface{}) {
 	if s.Logger != nil {
@@ -15,6 +15,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.scarlet.dev/rasa"
@@ -24,11 +25,15 @@ type testHandler1 struct{}
 type testHandlerNoEvent struct{}
 type testHandlerNoDispatch struct{}
 type testHandlerErr struct{}
+type testHandlerLazy struct{}
+type testHandlerReject struct{ reason string }
 
 func (testHandler1) ActionName() string          { return "action_test" }
 func (testHandlerNoEvent) ActionName() string    { return "action_no_event" }
 func (testHandlerNoDispatch) ActionName() string { return "action_no_dispatch" }
 func (testHandlerErr) ActionName() string        { return "action_error" }
+func (testHandlerLazy) ActionName() string       { return "action_lazy" }
+func (testHandlerReject) ActionName() string     { return "action_reject" }
 
 func (testHandler1) Run(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
 	dispatcher.Utter(&rasa.Message{
@@ -61,6 +66,30 @@ func (testHandlerErr) Run(ctx Context, dispatcher *CollectingDispatcher) (events
 	return
 }
 
+func (h testHandlerReject) Run(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+	err = perrors.Wrap(&ExecutionRejection{Reason: h.reason}, "validation failed")
+	return
+}
+
+func (testHandlerLazy) Run(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+	tracker := ctx.Tracker()
+	if _, ok := tracker.Events[0].(*rasa.LazyEvent); !ok {
+		err = errors.New("expected lazy events")
+		return
+	}
+
+	// echo the latest slot
+	it := tracker.Events.ReverseIter()
+	for it.Next() {
+		if e, ok := it.Event().(*rasa.SlotSet); ok {
+			events = append(events, e)
+			break
+		}
+	}
+	err = it.Err()
+	return
+}
+
 func TestServer(t *testing.T) {
 	handler := NewServer(
 		&testHandler1{},
@@ -242,6 +271,25 @@ func TestServer(t *testing.T) {
 			require.EqualValues(t, expect, result)
 		})
 		t.Run("action_error", func(t *testing.T) {
+			body := &Request{
+				NextAction: "action_error",
+				SenderID:   "TODO",
+			}
+
+			var result map[string]string
+			testRequest(
+				t,
+				"POST",
+				"https://example.com/webhook",
+				http.StatusInternalServerError,
+				body,
+				&result,
+			)
+
+			require.Equal(t, map[string]string{
+				"action_name": "action_error",
+				"error":       "error handling the action",
+			}, result)
 		})
 
 		t.Run("missing handler", func(t *testing.T) {
@@ -264,3 +312,152 @@ func TestServer(t *testing.T) {
 		})
 	})
 }
+
+// TestServerLazyEvents
+func TestServerLazyEvents(t *testing.T) {
+	handler := NewServer(&testHandlerLazy{})
+	handler.LazyEvents = true
+
+	body := []byte(`{
+		"next_action": "action_lazy",
+		"sender_id": "test",
+		"tracker": {
+			"sender_id": "test",
+			"slots": {"city": "Paris"},
+			"events": [
+				{"event": "action", "name": "action_listen"},
+				{"event": "slot", "name": "city", "value": "Paris"},
+				{"event": "user", "text": "hi"}
+			]
+		}
+	}`)
+
+	req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"events":[{"event":"slot","name":"city","value":"Paris"}],"responses":[]}`, w.Body.String())
+}
+
+// TestServerStrictSlots
+func TestServerStrictSlots(t *testing.T) {
+	handler := NewServer(&testHandlerNoDispatch{})
+	handler.StrictSlots = true
+
+	cases := []struct {
+		domain string
+		status int
+		expect string
+	}{
+		{
+			domain: `{"slots": {"test": {"type": "float", "max_value": 1000}}}`,
+			status: http.StatusOK,
+			expect: `{"events":[{"event":"slot","name":"test","value":420}],"responses":[]}`,
+		},
+		{
+			// values outside the range are stored by Rasa
+			domain: `{"slots": {"test": {"type": "float", "max_value": 100}}}`,
+			status: http.StatusOK,
+			expect: `{"events":[{"event":"slot","name":"test","value":420}],"responses":[]}`,
+		},
+		{
+			domain: `{"slots": {"test": {"type": "list"}}}`,
+			status: http.StatusInternalServerError,
+			expect: `{"action_name":"action_no_dispatch","error":"error handling the action"}`,
+		},
+		{
+			domain: `{"slots": {}}`,
+			status: http.StatusInternalServerError,
+			expect: `{"action_name":"action_no_dispatch","error":"error handling the action"}`,
+		},
+		{
+			// no domain, no validation
+			domain: `null`,
+			status: http.StatusOK,
+			expect: `{"events":[{"event":"slot","name":"test","value":"420"}],"responses":[]}`,
+		},
+	}
+
+	for i := range cases {
+		body := `{"next_action": "action_no_dispatch", "domain": ` + cases[i].domain + `}`
+		req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equalf(t, cases[i].status, w.Code, "failed on %d", i)
+		require.JSONEqf(t, cases[i].expect, w.Body.String(), "failed on %d", i)
+	}
+
+	t.Run("server domain", func(t *testing.T) {
+		handler := NewServer(&testHandlerNoDispatch{})
+		handler.StrictSlots = true
+		handler.Domain = &rasa.Domain{Slots: map[string]rasa.SlotDescription{
+			"test": {Type: "list"},
+		}}
+
+		req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte(`{"next_action": "action_no_dispatch"}`)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+// TestServerExecutionRejection
+func TestServerExecutionRejection(t *testing.T) {
+	cases := []struct {
+		handler *testHandlerReject
+		expect  string
+	}{
+		{
+			handler: &testHandlerReject{reason: "invalid city"},
+			expect:  `{"action_name":"action_reject","error":"invalid city"}`,
+		},
+		{
+			handler: &testHandlerReject{},
+			expect:  `{"action_name":"action_reject","error":"Custom action 'action_reject' rejected execution."}`,
+		},
+	}
+
+	for i := range cases {
+		handler := NewServer(cases[i].handler)
+		body := `{"next_action": "action_reject"}`
+		req := httptest.NewRequest("POST", "https://example.com/webhook", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equalf(t, http.StatusBadRequest, w.Code, "failed on %d", i)
+		require.JSONEqf(t, cases[i].expect, w.Body.String(), "failed on %d", i)
+	}
+}
+
+// TestServerPanic
+func TestServerPanic(t *testing.T) {
+	logger := &testLogger{}
+	handler := NewServer(&testHandlerPanic{}, &testHandler1{})
+	handler.Logger = logger
+
+	var reported []*HandlerPanicError
+	handler.OnPanic = func(ctx Context, err *HandlerPanicError) {
+		req, ok := RequestFrom(ctx)
+		require.True(t, ok)
+		require.Equal(t, "sender", req.SenderID)
+		reported = append(reported, err)
+	}
+
+	w := serveWebhook(handler, "action_panic")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.JSONEq(t, `{"action_name":"action_panic","error":"error handling the action"}`, w.Body.String())
+
+	require.Len(t, reported, 1)
+	require.Equal(t, "action_panic", reported[0].Action)
+	require.Equal(t, "test panic", reported[0].Value)
+	require.Contains(t, string(reported[0].Stack), "testHandlerPanic.Run")
+	require.Nil(t, reported[0].Unwrap())
+
+	lines := logger.matching("ERROR panic occured when handling action [action_panic]: test panic\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], "testHandlerPanic.Run")
+
+	// the server keeps serving requests
+	w = serveWebhook(handler, "action_test")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, reported, 1)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"context"
+	"time"
+
+	"go.scarlet.dev/rasa"
+)
+
+// DefaultTimeout is the timeout of handlers used by a Server without a
+// Timeout.
+const DefaultTimeout = 10 * time.Second
+
+// timeoutGrace is the time for which the Server waits for a handler after its
+// deadline, so that handlers which observe the deadline can still respond
+// with a partial response.
+const timeoutGrace = 50 * time.Millisecond
+
+// TimeoutHandler is implemented by handlers with a timeout other than the
+// timeout of the Server.
+type TimeoutHandler interface {
+	Handler
+
+	// Timeout returns the maximum duration of the execution of the handler.
+	// A zero duration selects the timeout of the Server, and a negative
+	// duration disables the timeout.
+	Timeout() time.Duration
+}
+
+// WithTimeout returns a TimeoutHandler which runs h with the provided
+// timeout. See TimeoutHandler.Timeout.
+func WithTimeout(h Handler, timeout time.Duration) TimeoutHandler {
+	return &timeoutHandler{Handler: h, timeout: timeout}
+}
+
+// timeoutHandler implements TimeoutHandler for WithTimeout.
+type timeoutHandler struct {
+	Handler
+	timeout time.Duration
+}
+
+// ensure interface
+var _ TimeoutHandler = (*timeoutHandler)(nil)
+
+// Timeout implements TimeoutHandler.
+func (h *timeoutHandler) Timeout() time.Duration {
+	return h.timeout
+}
+
+// handlerTimeout returns the timeout for the handler, which is negative if
+// the handler has no timeout.
+func (s *Server) handlerTimeout(handler Handler) time.Duration {
+	if h, ok := handler.(TimeoutHandler); ok {
+		if timeout := h.Timeout(); timeout != 0 {
+			return timeout
+		}
+	}
+	if s.Timeout != 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
+}
+
+// runHandlerWithTimeout runs the handler in a separate goroutine, and returns
+// a TimeoutError if it does not return within the grace period after the
+// deadline of ctx. The dispatcher must not be used after a TimeoutError is
+// returned, as the handler may still be running.
+func (s *Server) runHandlerWithTimeout(
+	ctx Context,
+	handler Handler,
+	dispatcher *CollectingDispatcher,
+	timeout time.Duration,
+) (rasa.Events, error) {
+	type result struct {
+		events rasa.Events
+		err    error
+	}
+	done := make(chan result, 1) // never blocks the handler
+	go func() {
+		events, err := s.runHandler(ctx, handler, dispatcher)
+		done <- result{events, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.events, res.err
+	case <-ctx.Context().Done():
+	}
+
+	if ctx.Context().Err() != context.DeadlineExceeded {
+		// the request was cancelled, such as by Rasa closing the connection
+		return nil, ctx.Context().Err()
+	}
+
+	grace := time.NewTimer(timeoutGrace)
+	defer grace.Stop()
+	select {
+	case res := <-done:
+		return res.events, res.err
+	case <-grace.C:
+		return nil, &TimeoutError{Action: handler.ActionName(), Timeout: timeout}
+	}
+}
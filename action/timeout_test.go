@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package action
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// testHandlerSlow sleeps for the duration, ignoring the context.
+type testHandlerSlow struct{ sleep time.Duration }
+
+// testHandlerDeadline waits for the deadline of the context, and returns the
+// context error, optionally as a partial response.
+type testHandlerDeadline struct{ partial bool }
+
+func (testHandlerSlow) ActionName() string     { return "action_slow" }
+func (testHandlerDeadline) ActionName() string { return "action_deadline" }
+
+func (h testHandlerSlow) Run(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+	time.Sleep(h.sleep)
+	dispatcher.Utter(&rasa.Message{Text: "done"})
+	return
+}
+
+func (h testHandlerDeadline) Run(ctx Context, dispatcher *CollectingDispatcher) (events rasa.Events, err error) {
+	dispatcher.Utter(&rasa.Message{Text: "looking it up"})
+	events = rasa.Events{&rasa.SlotSet{Key: "lookup", Value: "pending"}}
+
+	<-ctx.Context().Done()
+	err = ctx.Context().Err()
+	if h.partial {
+		err = &PartialResponseError{Cause: err}
+	}
+	return
+}
+
+// TestServerHandlerTimeout
+func TestServerHandlerTimeout(t *testing.T) {
+	handler := &testHandler1{}
+	cases := []struct {
+		server  time.Duration
+		handler Handler
+		expect  time.Duration
+	}{
+		{server: 0, handler: handler, expect: DefaultTimeout},
+		{server: time.Second, handler: handler, expect: time.Second},
+		{server: -1, handler: handler, expect: -1},
+		{server: time.Second, handler: WithTimeout(handler, time.Minute), expect: time.Minute},
+		{server: time.Second, handler: WithTimeout(handler, 0), expect: time.Second},
+		{server: time.Second, handler: WithTimeout(handler, -1), expect: -1},
+	}
+
+	for i := range cases {
+		s := &Server{Timeout: cases[i].server}
+		require.Equalf(t, cases[i].expect, s.handlerTimeout(cases[i].handler), "failed on %d", i)
+	}
+}
+
+// TestServerTimeout
+func TestServerTimeout(t *testing.T) {
+	cases := []struct {
+		handler Handler
+		status  int
+		expect  string
+		warn    string
+	}{
+		{
+			handler: &testHandlerSlow{sleep: 500 * time.Millisecond},
+			status:  http.StatusGatewayTimeout,
+			expect:  `{"action_name":"action_slow","error":"timeout handling the action"}`,
+		},
+		{
+			// the handler timeout extends the timeout of the server
+			handler: WithTimeout(&testHandlerSlow{sleep: 50 * time.Millisecond}, time.Second),
+			status:  http.StatusOK,
+			expect:  `{"events":[],"responses":[{"text":"done"}]}`,
+		},
+		{
+			handler: &testHandlerDeadline{},
+			status:  http.StatusGatewayTimeout,
+			expect:  `{"action_name":"action_deadline","error":"timeout handling the action"}`,
+		},
+		{
+			handler: &testHandlerDeadline{partial: true},
+			status:  http.StatusOK,
+			expect: `{
+				"events": [{"event":"slot","name":"lookup","value":"pending"}],
+				"responses": [{"text":"looking it up"}]
+			}`,
+			warn: "WARN action [action_deadline] responded partially",
+		},
+	}
+
+	for i := range cases {
+		logger := &testLogger{}
+		s := NewServer(cases[i].handler)
+		s.Timeout = 20 * time.Millisecond
+		s.Logger = logger
+
+		w := serveWebhook(s, cases[i].handler.ActionName())
+		require.Equalf(t, cases[i].status, w.Code, "failed on %d", i)
+		require.JSONEqf(t, cases[i].expect, w.Body.String(), "failed on %d", i)
+
+		if cases[i].warn != "" {
+			require.Lenf(t, logger.matching(cases[i].warn), 1, "failed on %d", i)
+		}
+	}
+}
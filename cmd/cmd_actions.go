@@ -12,7 +12,6 @@ import (
 	"go/format"
 	"io/ioutil"
 	"log"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -21,7 +20,7 @@ import (
 	perrors "github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	errors "go.scarlet.dev/errors"
-	"gopkg.in/yaml.v2"
+	"go.scarlet.dev/rasa"
 )
 
 //
@@ -66,16 +65,15 @@ var (
 			})
 
 			// Load the domain file
-			var config DomainYaml
-			func() {
-				log.Printf("loading domain from %s\n", rootCtx.DomainFile)
-				file, err := os.Open(rootCtx.DomainFile)
-				errors.Check(err)
-				defer file.Close()
-
-				err = yaml.NewDecoder(file).Decode(&config)
-				errors.Check(err)
-			}()
+			log.Printf("loading domain from %s\n", rootCtx.DomainFile)
+			config, err := rasa.LoadDomain(rootCtx.DomainFile)
+			if derr, ok := err.(*rasa.DomainError); ok {
+				for _, issue := range derr.Issues {
+					log.Printf("warning: %s\n", issue)
+				}
+				err = nil
+			}
+			errors.Check(err)
 
 			// TODO(ed): make sure ALL config slices are sorted
 			sort.Sort(sort.StringSlice(config.Actions))
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	perrors "github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	errors "go.scarlet.dev/errors"
+	"go.scarlet.dev/rasa"
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff <a.json> <b.json>",
+		Short: "print the differences between two trackers",
+		Long: `Diff prints the differences between two trackers, such as the trackers
+sent to the action server, as a human-readable report of the changed slots,
+active loop, and inserted or removed events.
+
+The files hold either a tracker, or a list of events.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer errors.Handle(&err, func(err error) error {
+				fmt.Println("encountered an error: " + err.Error())
+				return nil
+			})
+
+			a, err := loadTracker(args[0])
+			errors.Check(err)
+			b, err := loadTracker(args[1])
+			errors.Check(err)
+
+			fmt.Print(rasa.DiffTrackers(a, b).String())
+			return
+		},
+	}
+)
+
+// loadTracker loads a tracker from a JSON file holding either a tracker, or a
+// list of events. The state of the tracker is rebuilt from a list of events
+// by replaying them.
+func loadTracker(file string) (*rasa.Tracker, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := new(rasa.Tracker)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var events rasa.Events
+		if err = json.Unmarshal(data, &events); err == nil {
+			tracker.ReplayFrom(events)
+		}
+	} else {
+		err = json.Unmarshal(data, tracker)
+	}
+	return tracker, perrors.WithMessagef(err, "invalid tracker in [%s]", file)
+}
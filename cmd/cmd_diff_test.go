@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// TestLoadTracker
+func TestLoadTracker(t *testing.T) {
+	cases := []struct {
+		data   string
+		slots  rasa.Slots
+		loop   string
+		events int
+		err    bool
+	}{
+		{
+			data:   `{"sender_id": "test", "slots": {"city": "Paris"}, "events": []}`,
+			slots:  rasa.Slots{"city": "Paris"},
+			events: 0,
+		},
+		{
+			// the state of a list of events is replayed
+			data: `[
+				{"event": "action", "name": "action_listen"},
+				{"event": "slot", "name": "city", "value": "Paris"},
+				{"event": "active_loop", "name": "travel_form"}
+			]`,
+			slots:  rasa.Slots{"city": "Paris"},
+			loop:   "travel_form",
+			events: 3,
+		},
+		{data: `[{"event": "slot", "name": 1}]`, err: true},
+	}
+
+	for i := range cases {
+		f, err := ioutil.TempFile("", "tracker-*.json")
+		require.NoErrorf(t, err, "failed on %d", i)
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(cases[i].data)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.NoErrorf(t, f.Close(), "failed on %d", i)
+
+		tracker, err := loadTracker(f.Name())
+		if cases[i].err {
+			require.Errorf(t, err, "failed on %d", i)
+			continue
+		}
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.Equalf(t, cases[i].slots, tracker.Slots, "failed on %d", i)
+		require.Lenf(t, tracker.Events, cases[i].events, "failed on %d", i)
+		if cases[i].loop != "" {
+			require.Truef(t, tracker.ActiveLoop.Is(cases[i].loop), "failed on %d", i)
+		}
+	}
+}
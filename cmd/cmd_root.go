@@ -35,7 +35,7 @@ func init() {
 		&rootCtx.DomainFile,
 		"domain",
 		"domain.yml",
-		"Path to the rasa domain.yaml configuration file",
+		"Path to the rasa domain.yml file, or a directory of domain files",
 	)
 
 	rootCmd.MarkPersistentFlagFilename("domain")
@@ -20,3 +20,49 @@ const (
 	// DefaultRasaEndpoint is the default endpoint used by webhook clients.
 	DefaultRasaEndpoint = "http://localhost:5005"
 )
+
+// Names of Rasa's default actions which are referenced by the SDK.
+const (
+	// ActionListen is the name of the action Rasa executes to wait for the
+	// next user message.
+	ActionListen = "action_listen"
+
+	// ActionSessionStart is the name of the action Rasa executes at the start
+	// of a new conversation session.
+	ActionSessionStart = "action_session_start"
+
+	// ActionRestart is the name of the action Rasa executes to restart a
+	// conversation.
+	ActionRestart = "action_restart"
+)
+
+// Names of Rasa's default slots, which Rasa adds to every domain.
+const (
+	// SlotRequested is the name of the slot which holds the slot requested by
+	// the active form.
+	SlotRequested = "requested_slot"
+
+	// SlotSessionStartedMetadata is the name of the slot which holds the
+	// metadata of the user message which started the session.
+	SlotSessionStartedMetadata = "session_started_metadata"
+
+	// SlotKnowledgeBaseListedObjects is the name of the slot which holds the
+	// objects listed by the latest knowledge base action.
+	SlotKnowledgeBaseListedObjects = "knowledge_base_listed_objects"
+
+	// SlotKnowledgeBaseLastObject is the name of the slot which holds the
+	// object mentioned last in a knowledge base action.
+	SlotKnowledgeBaseLastObject = "knowledge_base_last_object"
+
+	// SlotKnowledgeBaseLastObjectType is the name of the slot which holds the
+	// type of the object mentioned last in a knowledge base action.
+	SlotKnowledgeBaseLastObjectType = "knowledge_base_last_object_type"
+)
+
+// Names of Rasa's default intents which are referenced by the SDK.
+const (
+	// IntentNLUFallback is the name of the intent predicted by Rasa's
+	// FallbackClassifier when the confidence of the NLU prediction is too
+	// low.
+	IntentNLUFallback = "nlu_fallback"
+)
@@ -0,0 +1,406 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TrackerDiff holds the differences between two trackers.
+type TrackerDiff struct {
+	// Fields holds the changes of the scalar fields of the tracker, such as
+	// the latest action name.
+	Fields []FieldChange
+
+	// Slots holds the changes of the slots, sorted by name.
+	Slots []SlotChange
+
+	// ActiveLoop holds the change of the active loop, or nil if the active
+	// loop did not change.
+	ActiveLoop *FieldChange
+
+	// Events holds the inserted and removed events.
+	Events []EventChange
+}
+
+// FieldChange holds the change of a field of the tracker.
+type FieldChange struct {
+	// Name holds the JSON name of the field.
+	Name string
+
+	// Old holds the value in the first tracker.
+	Old interface{}
+
+	// New holds the value in the second tracker.
+	New interface{}
+}
+
+// SlotChange holds the change of a slot.
+type SlotChange struct {
+	// Name holds the name of the slot.
+	Name string
+
+	// Old holds the value in the first tracker.
+	Old interface{}
+
+	// New holds the value in the second tracker.
+	New interface{}
+
+	// Added indicates that the slot is only present in the second tracker.
+	Added bool
+
+	// Removed indicates that the slot is only present in the first tracker.
+	Removed bool
+}
+
+// EventChangeKind describes whether an event was inserted or removed.
+type EventChangeKind int
+
+// Kinds of event changes.
+const (
+	// EventRemoved indicates an event which is only present in the first
+	// sequence.
+	EventRemoved EventChangeKind = iota
+
+	// EventInserted indicates an event which is only present in the second
+	// sequence.
+	EventInserted
+)
+
+// EventChange holds an event which was inserted or removed.
+type EventChange struct {
+	// Kind holds whether the event was inserted or removed.
+	Kind EventChangeKind
+
+	// Index holds the index of the event in the first sequence for removed
+	// events, and in the second sequence for inserted events.
+	Index int
+
+	// Event holds the event.
+	Event Event
+}
+
+// DiffTrackers returns the differences between the trackers a and b. Events
+// are compared with DiffEvents.
+func DiffTrackers(a, b *Tracker) *TrackerDiff {
+	diff := &TrackerDiff{
+		Slots:  diffSlots(a.Slots, b.Slots),
+		Events: DiffEvents(a.Events, b.Events),
+	}
+
+	fields := []FieldChange{
+		{Name: "sender_id", Old: a.SenderID, New: b.SenderID},
+		{Name: "latest_action_name", Old: a.LatestActionName, New: b.LatestActionName},
+		{Name: "latest_input_channel", Old: a.LatestInputChannel, New: b.LatestInputChannel},
+		{Name: "followup_action", Old: a.FollowupAction, New: b.FollowupAction},
+		{Name: "paused", Old: a.Paused, New: b.Paused},
+	}
+	for i := range fields {
+		if fields[i].Old != fields[i].New {
+			diff.Fields = append(diff.Fields, fields[i])
+		}
+	}
+
+	if oldLoop, newLoop := loopName(a.ActiveLoop), loopName(b.ActiveLoop); oldLoop != newLoop {
+		diff.ActiveLoop = &FieldChange{Name: "active_loop", Old: oldLoop, New: newLoop}
+	}
+	return diff
+}
+
+// loopName returns the name of the active loop, or an empty string.
+func loopName(loop *TActiveLoop) string {
+	if !loop.IsActive() {
+		return ""
+	}
+	return loop.Name
+}
+
+// diffSlots returns the changes of the slots, sorted by name.
+func diffSlots(a, b Slots) (changes []SlotChange) {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, exists := a[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldValue, inA := a[name]
+		newValue, inB := b[name]
+		if inA && inB && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, SlotChange{
+			Name:    name,
+			Old:     oldValue,
+			New:     newValue,
+			Added:   !inA,
+			Removed: !inB,
+		})
+	}
+	return
+}
+
+// DiffEvents returns the events which were removed from a and inserted into
+// b, based on their longest common subsequence. Removed events are reported
+// before inserted events at the same position.
+//
+// Events are compared by their JSON representation, ignoring timestamps, so
+// that the events of a replayed conversation match the original events.
+func DiffEvents(a, b Events) (changes []EventChange) {
+	keysA, keysB := eventKeys(a), eventKeys(b)
+
+	// skip the common prefix and suffix, which is often most of the events
+	start := 0
+	for start < len(a) && start < len(b) && keysA[start] == keysB[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && keysA[endA-1] == keysB[endB-1] {
+		endA--
+		endB--
+	}
+
+	// the events are compared by their index in the interned keys, and the
+	// longest common subsequence is computed in linear space, as the events
+	// of long conversations could otherwise require a quadratic table
+	ids := make(map[string]int)
+	intern := func(keys []string) []int {
+		result := make([]int, len(keys))
+		for i, key := range keys {
+			id, exists := ids[key]
+			if !exists {
+				id = len(ids)
+				ids[key] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+	idsA, idsB := intern(keysA[start:endA]), intern(keysB[start:endB])
+
+	var matches [][2]int
+	lcsMatches(idsA, idsB, 0, 0, &matches)
+	matches = append(matches, [2]int{len(idsA), len(idsB)})
+
+	// removed events are reported before inserted events between matches
+	i, j := 0, 0
+	for _, match := range matches {
+		for ; i < match[0]; i++ {
+			changes = append(changes, EventChange{Kind: EventRemoved, Index: start + i, Event: a[start+i]})
+		}
+		for ; j < match[1]; j++ {
+			changes = append(changes, EventChange{Kind: EventInserted, Index: start + j, Event: b[start+j]})
+		}
+		i++
+		j++
+	}
+	return
+}
+
+// lcsMatches appends the pairs of indices of a longest common subsequence of
+// a and b to matches, offset by offA and offB, in increasing order. It uses
+// Hirschberg's algorithm, which requires O(len(a)*len(b)) time but only
+// O(len(b)) space.
+func lcsMatches(a, b []int, offA, offB int, matches *[][2]int) {
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	if len(a) == 1 {
+		for j := range b {
+			if a[0] == b[j] {
+				*matches = append(*matches, [2]int{offA, offB + j})
+				return
+			}
+		}
+		return
+	}
+
+	// split b where the common subsequences of both halves of a are longest
+	mid := len(a) / 2
+	forward := lcsLengths(a[:mid], b, false)
+	backward := lcsLengths(a[mid:], b, true)
+	split, best := 0, -1
+	for k := 0; k <= len(b); k++ {
+		if length := forward[k] + backward[len(b)-k]; length > best {
+			split, best = k, length
+		}
+	}
+
+	lcsMatches(a[:mid], b[:split], offA, offB, matches)
+	lcsMatches(a[mid:], b[split:], offA+mid, offB+split, matches)
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of a and
+// every prefix of b, indexed by the length of the prefix. If reverse is
+// true, the suffixes of a and b are compared instead, indexed by the length
+// of the suffix of b.
+func lcsLengths(a, b []int, reverse bool) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		x := a[i]
+		if reverse {
+			x = a[len(a)-1-i]
+		}
+		for j := range b {
+			y := b[j]
+			if reverse {
+				y = b[len(b)-1-j]
+			}
+			switch {
+			case x == y:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// eventKeys returns the keys by which the events are compared.
+func eventKeys(events Events) []string {
+	keys := make([]string, len(events))
+	for i := range events {
+		keys[i] = eventKey(events[i])
+	}
+	return keys
+}
+
+// eventKey returns the JSON representation of the event without its
+// timestamp, with sorted keys.
+func eventKey(evt Event) string {
+	data, err := json.Marshal(eventPointer(evt))
+	if err != nil {
+		return fmt.Sprintf("%#v", evt)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return string(data)
+	}
+	delete(m, "timestamp")
+
+	// maps are marshalled with sorted keys
+	data, _ = json.Marshal(m)
+	return string(data)
+}
+
+// Empty returns whether the trackers are equal.
+func (d *TrackerDiff) Empty() bool {
+	return len(d.Fields) == 0 && len(d.Slots) == 0 && d.ActiveLoop == nil && len(d.Events) == 0
+}
+
+// String implements fmt.Stringer.
+//
+// String returns a human-readable report of the differences, in which
+// removed values are prefixed with "-", added values with "+", and changed
+// values with "~".
+func (d *TrackerDiff) String() string {
+	if d.Empty() {
+		return "no differences\n"
+	}
+
+	var b strings.Builder
+	for _, f := range d.Fields {
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", f.Name, formatDiffValue(f.Old), formatDiffValue(f.New))
+	}
+	if d.ActiveLoop != nil {
+		fmt.Fprintf(&b, "~ active_loop: %s -> %s\n", formatLoop(d.ActiveLoop.Old), formatLoop(d.ActiveLoop.New))
+	}
+
+	if len(d.Slots) > 0 {
+		b.WriteString("slots:\n")
+		for _, s := range d.Slots {
+			switch {
+			case s.Added:
+				fmt.Fprintf(&b, "  + %s: %s\n", s.Name, formatDiffValue(s.New))
+			case s.Removed:
+				fmt.Fprintf(&b, "  - %s: %s\n", s.Name, formatDiffValue(s.Old))
+			default:
+				fmt.Fprintf(&b, "  ~ %s: %s -> %s\n", s.Name, formatDiffValue(s.Old), formatDiffValue(s.New))
+			}
+		}
+	}
+
+	if len(d.Events) > 0 {
+		b.WriteString("events:\n")
+		b.WriteString(FormatEventChanges(d.Events))
+	}
+	return b.String()
+}
+
+// FormatEventChanges returns a human-readable report of the event changes,
+// with a line for every event.
+func FormatEventChanges(changes []EventChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		prefix := "-"
+		if c.Kind == EventInserted {
+			prefix = "+"
+		}
+		fmt.Fprintf(&b, "  %s [%d] %s\n", prefix, c.Index, DescribeEvent(c.Event))
+	}
+	return b.String()
+}
+
+// DescribeEvent returns a short, human-readable description of the event,
+// such as `slot city="Paris"`.
+func DescribeEvent(evt Event) string {
+	switch e := eventPointer(evt).(type) {
+	case *UserUttered:
+		desc := "user " + formatDiffValue(e.Text)
+		if e.ParseData != nil && e.ParseData.Intent.Name != "" {
+			desc += " (intent " + e.ParseData.Intent.Name + ")"
+		}
+		return desc
+	case *BotUttered:
+		return "bot " + formatDiffValue(e.Text)
+	case *ActionExecuted:
+		if e.ActionName == "" {
+			return "action text " + formatDiffValue(e.ActionText)
+		}
+		return "action " + e.ActionName
+	case *SlotSet:
+		return "slot " + e.Key + "=" + formatDiffValue(e.Value)
+	case *ActiveLoop:
+		return "active_loop " + formatLoop(e.Name)
+	case *FollowupAction:
+		return "followup " + e.ActionName
+	}
+
+	key := eventKey(evt)
+	return string(evt.Type()) + " " + key
+}
+
+// formatDiffValue formats a value as JSON.
+func formatDiffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// formatLoop formats the name of a loop, which is empty for no loop.
+func formatLoop(name interface{}) string {
+	if name == "" {
+		return "null"
+	}
+	return fmt.Sprint(name)
+}
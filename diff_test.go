@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffEvents
+func TestDiffEvents(t *testing.T) {
+	listen := &ActionExecuted{ActionName: ActionListen}
+	greet := &UserUttered{Text: "hi", ParseData: &ParseResult{Intent: Intent{Name: "greet"}}}
+	utter := &ActionExecuted{ActionName: "utter_greet"}
+	paris := &SlotSet{Key: "city", Value: "Paris"}
+	rome := &SlotSet{Key: "city", Value: "Rome"}
+
+	cases := []struct {
+		a, b   Events
+		expect []EventChange
+	}{
+		{
+			a: Events{listen, greet, utter},
+			b: Events{listen, greet, utter},
+		},
+		{
+			// timestamps and value events are ignored
+			a: Events{&ActionExecuted{ActionName: "utter_greet", Timestamp: Time(time.Unix(1, 0))}},
+			b: Events{ActionExecuted{ActionName: "utter_greet"}},
+		},
+		{
+			a: Events{listen, greet, utter},
+			b: Events{listen, greet, paris, utter},
+			expect: []EventChange{
+				{Kind: EventInserted, Index: 2, Event: paris},
+			},
+		},
+		{
+			a: Events{listen, greet, paris, utter, listen},
+			b: Events{listen, rome, utter},
+			expect: []EventChange{
+				{Kind: EventRemoved, Index: 1, Event: greet},
+				{Kind: EventRemoved, Index: 2, Event: paris},
+				{Kind: EventInserted, Index: 1, Event: rome},
+				{Kind: EventRemoved, Index: 4, Event: listen},
+			},
+		},
+		{
+			a: nil,
+			b: Events{utter},
+			expect: []EventChange{
+				{Kind: EventInserted, Index: 0, Event: utter},
+			},
+		},
+	}
+
+	for i := range cases {
+		require.Equalf(t, cases[i].expect, DiffEvents(cases[i].a, cases[i].b), "failed on %d", i)
+	}
+}
+
+// TestDiffEventsLong
+func TestDiffEventsLong(t *testing.T) {
+	// a repeats every multiple of 3 three times, and b every multiple of 5
+	// five times
+	var a, b Events
+	for i := 0; i < 3000; i++ {
+		a = append(a, &SlotSet{Key: "n", Value: i - i%3})
+		b = append(b, &SlotSet{Key: "n", Value: i - i%5})
+	}
+
+	changes := DiffEvents(a, b)
+	removed, inserted := make(map[int]bool), make(map[int]bool)
+	for _, c := range changes {
+		if c.Kind == EventRemoved {
+			removed[c.Index] = true
+		} else {
+			inserted[c.Index] = true
+		}
+	}
+
+	// the remaining events match
+	var keptA, keptB []string
+	for i := range a {
+		if !removed[i] {
+			keptA = append(keptA, eventKey(a[i]))
+		}
+		if !inserted[i] {
+			keptB = append(keptB, eventKey(b[i]))
+		}
+	}
+	require.Equal(t, keptA, keptB)
+
+	// the common subsequence holds the multiples of 15 three times
+	require.Len(t, keptA, 600)
+}
+
+// TestDiffTrackers
+func TestDiffTrackers(t *testing.T) {
+	a := &Tracker{
+		SenderID:         "user",
+		Slots:            Slots{"city": "Paris", "people": 2.0, "cuisine": nil},
+		LatestActionName: "restaurant_form",
+		ActiveLoop:       &TActiveLoop{Name: "restaurant_form"},
+		Events: Events{
+			&ActionExecuted{ActionName: "restaurant_form"},
+			&SlotSet{Key: "city", Value: "Paris"},
+		},
+	}
+	b := &Tracker{
+		SenderID:         "user",
+		Slots:            Slots{"city": "Rome", "cuisine": nil, "time": "now"},
+		LatestActionName: "utter_booked",
+		Events: Events{
+			&ActionExecuted{ActionName: "restaurant_form"},
+			&SlotSet{Key: "city", Value: "Rome"},
+			&ActiveLoop{},
+			&BotUttered{Text: "Booked!"},
+		},
+	}
+
+	diff := DiffTrackers(a, b)
+	require.False(t, diff.Empty())
+	require.Equal(t, []SlotChange{
+		{Name: "city", Old: "Paris", New: "Rome"},
+		{Name: "people", Old: 2.0, Removed: true},
+		{Name: "time", New: "now", Added: true},
+	}, diff.Slots)
+	require.Equal(t, &FieldChange{Name: "active_loop", Old: "restaurant_form", New: ""}, diff.ActiveLoop)
+	require.Equal(t, []FieldChange{
+		{Name: "latest_action_name", Old: "restaurant_form", New: "utter_booked"},
+	}, diff.Fields)
+	require.Len(t, diff.Events, 4)
+
+	require.Equal(t, `~ latest_action_name: "restaurant_form" -> "utter_booked"
+~ active_loop: restaurant_form -> null
+slots:
+  ~ city: "Paris" -> "Rome"
+  - people: 2
+  + time: "now"
+events:
+  - [1] slot city="Paris"
+  + [1] slot city="Rome"
+  + [2] active_loop null
+  + [3] bot "Booked!"
+`, diff.String())
+
+	same := DiffTrackers(a, a)
+	require.True(t, same.Empty())
+	require.Equal(t, "no differences\n", same.String())
+}
+
+// TestDescribeEvent
+func TestDescribeEvent(t *testing.T) {
+	cases := []struct {
+		evt    Event
+		expect string
+	}{
+		{&UserUttered{Text: "hi", ParseData: &ParseResult{Intent: Intent{Name: "greet"}}}, `user "hi" (intent greet)`},
+		{&ActionExecuted{ActionText: "Hello"}, `action text "Hello"`},
+		{&ActiveLoop{Name: "form"}, `active_loop form`},
+		{&FollowupAction{ActionName: "utter_greet"}, `followup utter_greet`},
+		{&Restarted{Timestamp: Time(time.Unix(1, 0))}, `restart {"event":"restart"}`},
+	}
+
+	for i := range cases {
+		require.Equalf(t, cases[i].expect, DescribeEvent(cases[i].evt), "failed on %d", i)
+	}
+}
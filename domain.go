@@ -6,15 +6,145 @@
 
 package rasa
 
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+)
+
 // Domain contains the configuration of the AI's domain as sent to the action
 // server by Rasa's engine.
+//
+// Both the Rasa 2.x and 3.x formats of the domain are supported.
 type Domain struct {
-	Config    DomainConfig                     `json:"config"`
-	Intents   []IntentDescription              `json:"intents"`
-	Entities  []string                         `json:"entities"`
-	Slots     map[string]SlotDescription       `json:"slots"`
-	Responses map[string][]TemplateDescription `json:"responses"`
-	Actions   []string                         `json:"actions"`
+	Version       string                           `json:"version,omitempty"`
+	Config        DomainConfig                     `json:"config"`
+	SessionConfig SessionConfig                    `json:"session_config"`
+	Intents       []IntentDescription              `json:"intents"`
+	Entities      []EntityDescription              `json:"entities"`
+	Slots         map[string]SlotDescription       `json:"slots"`
+	Responses     map[string][]ResponseDescription `json:"responses"`
+	Actions       []string                         `json:"actions"`
+	Forms         map[string]FormDescription       `json:"forms,omitempty"`
+	E2EActions    []string                         `json:"e2e_actions,omitempty"`
+}
+
+// ensure interface
+var _ json.Unmarshaler = (*Domain)(nil)
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Actions may be listed either by name, or as a single-key object holding
+// the action's settings, such as `{"action_check": {"send_domain": true}}`.
+func (d *Domain) UnmarshalJSON(data []byte) (err error) {
+	type alias Domain
+	aux := struct {
+		*alias
+		Actions []json.RawMessage `json:"actions"`
+	}{
+		alias: (*alias)(d),
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	d.Actions = nil
+	for i := range aux.Actions {
+		var name string
+		if name, _, err = unmarshalNamed(aux.Actions[i]); err != nil {
+			return perrors.WithMessagef(err, "invalid action at index %d", i)
+		}
+		d.Actions = append(d.Actions, name)
+	}
+	return
+}
+
+// HasAction returns whether the domain contains an action with the provided
+// name. Custom actions, responses, and forms are considered actions.
+func (d *Domain) HasAction(name string) bool {
+	if sliceContains(d.Actions, name) {
+		return true
+	}
+	if _, ok := d.Responses[name]; ok {
+		return true
+	}
+	_, ok := d.Forms[name]
+	return ok
+}
+
+// HasIntent returns whether the domain contains the intent.
+func (d *Domain) HasIntent(name string) bool {
+	_, ok := d.Intent(name)
+	return ok
+}
+
+// Intent returns the description of the intent. The ok flag indicates
+// whether the intent is present in the domain.
+func (d *Domain) Intent(name string) (intent IntentDescription, ok bool) {
+	for i := range d.Intents {
+		if d.Intents[i].Name == name {
+			return d.Intents[i], true
+		}
+	}
+	return
+}
+
+// IntentNames returns the names of all intents in the domain.
+func (d *Domain) IntentNames() []string {
+	names := make([]string, len(d.Intents))
+	for i := range d.Intents {
+		names[i] = d.Intents[i].Name
+	}
+	return names
+}
+
+// EntityNames returns the names of all entities in the domain.
+func (d *Domain) EntityNames() []string {
+	names := make([]string, len(d.Entities))
+	for i := range d.Entities {
+		names[i] = d.Entities[i].Name
+	}
+	return names
+}
+
+// Slot returns the description of the slot. The ok flag indicates whether
+// the slot is present in the domain.
+func (d *Domain) Slot(name string) (slot SlotDescription, ok bool) {
+	slot, ok = d.Slots[name]
+	return
+}
+
+// Response returns the variations of the response. The ok flag indicates
+// whether the response is present in the domain.
+func (d *Domain) Response(name string) (variations []ResponseDescription, ok bool) {
+	variations, ok = d.Responses[name]
+	return
+}
+
+// Form returns the description of the form. The ok flag indicates whether the
+// form is present in the domain.
+func (d *Domain) Form(name string) (form FormDescription, ok bool) {
+	form, ok = d.Forms[name]
+	return
+}
+
+// FormRequiredSlots returns the names of the slots required by the form, in
+// the order in which they are requested. Nil is returned if the form is not
+// present in the domain.
+func (d *Domain) FormRequiredSlots(form string) []string {
+	return d.Forms[form].RequiredSlots
+}
+
+// InitialSlots returns the initial values of the slots in the domain, which
+// can be used as Tracker.InitialSlots.
+func (d *Domain) InitialSlots() Slots {
+	slots := make(Slots, len(d.Slots))
+	for name := range d.Slots {
+		slots[name] = d.Slots[name].InitialValue
+	}
+	return slots
 }
 
 // DomainConfig contains domain settings.
@@ -22,21 +152,510 @@ type DomainConfig struct {
 	StoreEntitiesAsSlots bool `json:"store_entities_as_slots"`
 }
 
+// SessionConfig contains the settings of conversation sessions.
+type SessionConfig struct {
+	// SessionExpirationTime holds the time in minutes after which a new
+	// session is started. A value of 0 disables session expiration.
+	SessionExpirationTime float64 `json:"session_expiration_time"`
+
+	// CarryOverSlots indicates whether the slots are carried over to a new
+	// session.
+	CarryOverSlots bool `json:"carry_over_slots_to_new_session"`
+}
+
 // IntentDescription contains a domain intent description.
+//
+// In the domain, an intent is either listed by name, or as a single-key
+// object holding the intent's settings.
 type IntentDescription struct {
-	UseEntities bool `json:"use_entities"`
+	// Name holds the name of the intent.
+	Name string
+
+	// UseEntities indicates whether the entities of the intent are used for
+	// featurization. If UsedEntities is set, only the listed entities are
+	// used. Entities in IgnoreEntities are never used.
+	UseEntities bool
+
+	// UsedEntities holds the entities used for featurization, if the domain
+	// lists them explicitly.
+	UsedEntities []string
+
+	// IgnoreEntities holds the entities not used for featurization.
+	IgnoreEntities []string
+
+	// Triggers holds the name of the action triggered by the intent.
+	Triggers string
+
+	// IsRetrievalIntent indicates whether the intent is a retrieval intent.
+	IsRetrievalIntent bool
+}
+
+// intentProperties holds the serialized settings of an intent.
+type intentProperties struct {
+	UseEntities       json.RawMessage `json:"use_entities,omitempty"`
+	IgnoreEntities    []string        `json:"ignore_entities,omitempty"`
+	Triggers          string          `json:"triggers,omitempty"`
+	IsRetrievalIntent bool            `json:"is_retrieval_intent,omitempty"`
+}
+
+// ensure interfaces
+var _ json.Marshaler = (IntentDescription{})
+var _ json.Unmarshaler = (*IntentDescription)(nil)
+
+// UsesEntity returns whether the entity is used for featurization.
+func (i *IntentDescription) UsesEntity(entity string) bool {
+	if sliceContains(i.IgnoreEntities, entity) {
+		return false
+	}
+	if i.UsedEntities != nil {
+		return sliceContains(i.UsedEntities, entity)
+	}
+	return i.UseEntities
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *IntentDescription) UnmarshalJSON(data []byte) (err error) {
+	name, props, err := unmarshalNamed(data)
+	if err != nil {
+		return perrors.WithMessage(err, "invalid intent")
+	}
+
+	*i = IntentDescription{Name: name, UseEntities: true}
+	if props == nil {
+		return
+	}
+
+	var aux intentProperties
+	if err = json.Unmarshal(props, &aux); err != nil {
+		return perrors.WithMessagef(err, "invalid intent [%s]", name)
+	}
+	i.IgnoreEntities = aux.IgnoreEntities
+	i.Triggers = aux.Triggers
+	i.IsRetrievalIntent = aux.IsRetrievalIntent
+
+	// use_entities is either a boolean, or a list of entities
+	switch use := bytes.TrimSpace(aux.UseEntities); {
+	case len(use) == 0, string(use) == "null":
+	case use[0] == '[':
+		i.UsedEntities = []string{}
+		err = json.Unmarshal(use, &i.UsedEntities)
+	default:
+		err = json.Unmarshal(use, &i.UseEntities)
+	}
+	return perrors.WithMessagef(err, "invalid use_entities of intent [%s]", name)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i IntentDescription) MarshalJSON() ([]byte, error) {
+	props := intentProperties{
+		IgnoreEntities:    i.IgnoreEntities,
+		Triggers:          i.Triggers,
+		IsRetrievalIntent: i.IsRetrievalIntent,
+	}
+	switch {
+	case i.UsedEntities != nil:
+		props.UseEntities, _ = json.Marshal(i.UsedEntities)
+	case !i.UseEntities:
+		props.UseEntities = json.RawMessage("false")
+	}
+	nameOnly := props.UseEntities == nil && len(props.IgnoreEntities) == 0 &&
+		props.Triggers == "" && !props.IsRetrievalIntent
+	return marshalNamed(i.Name, props, nameOnly)
+}
+
+// EntityDescription contains a domain entity description.
+//
+// In the domain, an entity is either listed by name, or as a single-key
+// object holding the entity's roles and groups.
+type EntityDescription struct {
+	Name   string
+	Roles  []string
+	Groups []string
+}
+
+// entityProperties holds the serialized settings of an entity.
+type entityProperties struct {
+	Roles  []string `json:"roles,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ensure interfaces
+var _ json.Marshaler = (EntityDescription{})
+var _ json.Unmarshaler = (*EntityDescription)(nil)
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *EntityDescription) UnmarshalJSON(data []byte) (err error) {
+	name, props, err := unmarshalNamed(data)
+	if err != nil {
+		return perrors.WithMessage(err, "invalid entity")
+	}
+
+	*e = EntityDescription{Name: name}
+	if props == nil {
+		return
+	}
+
+	var aux entityProperties
+	if err = json.Unmarshal(props, &aux); err != nil {
+		return perrors.WithMessagef(err, "invalid entity [%s]", name)
+	}
+	e.Roles = aux.Roles
+	e.Groups = aux.Groups
+	return
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e EntityDescription) MarshalJSON() ([]byte, error) {
+	return marshalNamed(e.Name, entityProperties{
+		Roles:  e.Roles,
+		Groups: e.Groups,
+	}, len(e.Roles) == 0 && len(e.Groups) == 0)
 }
 
 // SlotDescription contains a domain slot description.
 type SlotDescription struct {
-	AutoFill     bool     `json:"auto_fill"`
-	Type         string   `json:"type"`
-	InitialValue string   `json:"initial_value,omitempty"`
-	Values       []string `json:"values,omitempty"`
+	// Type holds the type of the slot, such as "text" or "categorical". For
+	// custom slots, the type holds the module path of the slot class.
+	Type string `json:"type"`
+
+	// InitialValue holds the value of the slot at the start of a
+	// conversation.
+	InitialValue interface{} `json:"initial_value"`
+
+	// Values holds the possible values of a categorical slot.
+	Values []string `json:"values,omitempty"`
+
+	// MinValue and MaxValue hold the range of a float slot.
+	MinValue *float64 `json:"min_value,omitempty"`
+	MaxValue *float64 `json:"max_value,omitempty"`
+
+	// InfluenceConversation indicates whether the slot influences the
+	// predictions of the dialogue policies. Rasa defaults to true for all
+	// slot types except "any".
+	InfluenceConversation *bool `json:"influence_conversation,omitempty"`
+
+	// Mappings holds the slot mappings, used to fill the slot.
+	Mappings []SlotMapping `json:"mappings,omitempty"`
+
+	// AutoFill indicates whether the slot is filled from entities with the
+	// same name. AutoFill was removed in Rasa 3.
+	AutoFill bool `json:"auto_fill,omitempty"`
+}
+
+// slotClassTypes maps the names of Rasa's slot classes to their type.
+var slotClassTypes = map[string]string{
+	"TextSlot":         "text",
+	"BooleanSlot":      "bool",
+	"CategoricalSlot":  "categorical",
+	"FloatSlot":        "float",
+	"ListSlot":         "list",
+	"AnySlot":          "any",
+	"UnfeaturizedSlot": "unfeaturized",
+}
+
+// Kind returns the normalized type of the slot.
+//
+// Rasa sends the type of a slot either by name, or as the module path of the
+// slot class, such as "rasa.shared.core.slots.TextSlot". The class paths of
+// Rasa's slots are normalized to the name of their type. The type of custom
+// slots is returned unchanged.
+func (s *SlotDescription) Kind() string {
+	typ := s.Type
+	if strings.HasPrefix(typ, "rasa.") {
+		if name, ok := slotClassTypes[typ[strings.LastIndexByte(typ, '.')+1:]]; ok {
+			return name
+		}
+	}
+	return typ
+}
+
+// Influences returns whether the slot influences the conversation, taking
+// Rasa's default into account.
+func (s *SlotDescription) Influences() bool {
+	if s.InfluenceConversation != nil {
+		return *s.InfluenceConversation
+	}
+	return s.Kind() != "any" && s.Kind() != "unfeaturized"
+}
+
+// SlotMapping describes how a slot is filled.
+type SlotMapping struct {
+	// Type holds the type of the mapping, such as "from_entity", "from_text",
+	// "from_intent", "from_trigger_intent", or "custom".
+	Type string `json:"type"`
+
+	// Entity, Role, and Group select the entity of a "from_entity" mapping.
+	Entity string `json:"entity,omitempty"`
+	Role   string `json:"role,omitempty"`
+	Group  string `json:"group,omitempty"`
+
+	// Intent and NotIntent restrict the intents for which the mapping
+	// applies.
+	Intent    StringList `json:"intent,omitempty"`
+	NotIntent StringList `json:"not_intent,omitempty"`
+
+	// Value holds the value of "from_intent" and "from_trigger_intent"
+	// mappings.
+	Value interface{} `json:"value,omitempty"`
+
+	// Action holds the name of the action of a "custom" mapping.
+	Action string `json:"action,omitempty"`
+
+	// Conditions restrict the mapping to specific active loops and requested
+	// slots.
+	Conditions []MappingCondition `json:"conditions,omitempty"`
+}
+
+// MappingCondition restricts a slot mapping.
+type MappingCondition struct {
+	ActiveLoop    string `json:"active_loop,omitempty"`
+	RequestedSlot string `json:"requested_slot,omitempty"`
+}
+
+// StringList holds a list of strings, which is serialized in the domain as
+// either a single string or a list.
+type StringList []string
+
+// ensure interface
+var _ json.Unmarshaler = (*StringList)(nil)
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*l = StringList{value}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// ResponseDescription contains a single variation of a domain response.
+type ResponseDescription struct {
+	// ID optionally identifies the variation.
+	ID string `json:"id,omitempty"`
+
+	Text       string      `json:"text,omitempty"`
+	Image      string      `json:"image,omitempty"`
+	Buttons    []Button    `json:"buttons,omitempty"`
+	Attachment interface{} `json:"attachment,omitempty"`
+	Elements   []JSONMap   `json:"elements,omitempty"`
+	Custom     JSONMap     `json:"custom,omitempty"`
+	// ButtonType holds the way buttons are displayed, such as "vertical".
+	ButtonType string `json:"button_type,omitempty"`
+
+	// Channel restricts the variation to a single output channel.
+	Channel string `json:"channel,omitempty"`
+
+	// Condition restricts the variation to conversations in which the
+	// conditions hold.
+	Condition []ResponseCondition `json:"condition,omitempty"`
+
+	// Metadata holds the metadata of the response.
+	Metadata JSONMap `json:"metadata,omitempty"`
 }
 
-// TemplateDescription contains a domain template description.
-type TemplateDescription struct {
-	// the template text
-	Text string
+// TemplateDescription is the name used by Rasa 1.x for ResponseDescription.
+type TemplateDescription = ResponseDescription
+
+// ResponseCondition holds a condition of a conditional response variation.
+type ResponseCondition struct {
+	// Type holds the type of the condition. Rasa only supports "slot".
+	Type string `json:"type"`
+
+	// Name holds the name of the slot.
+	Name string `json:"name"`
+
+	// Value holds the value the slot must have.
+	Value interface{} `json:"value"`
+}
+
+// FormDescription contains a domain form description.
+type FormDescription struct {
+	// RequiredSlots holds the names of the slots the form requests, in
+	// order.
+	RequiredSlots []string
+
+	// IgnoredIntents holds the intents for which no slots are filled.
+	IgnoredIntents StringList
+
+	// SlotMappings holds the slot mappings defined in the form. Slot mappings
+	// are defined in forms in Rasa 2.x, and in slots in Rasa 3.x.
+	SlotMappings map[string][]SlotMapping
+}
+
+// ensure interfaces
+var _ json.Marshaler = (FormDescription{})
+var _ json.Unmarshaler = (*FormDescription)(nil)
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// The required slots are either a list of slot names, or an object mapping
+// the slot names to their mappings. Forms of early versions of Rasa 2.x
+// list the slot mappings at the root level of the form.
+func (f *FormDescription) UnmarshalJSON(data []byte) (err error) {
+	*f = FormDescription{}
+
+	keys, values, err := unmarshalOrderedObject(data)
+	if err != nil {
+		return perrors.WithMessage(err, "invalid form")
+	}
+
+	required := -1
+	for i := range keys {
+		switch keys[i] {
+		case "required_slots":
+			required = i
+		case "ignored_intents":
+			if err = json.Unmarshal(values[i], &f.IgnoredIntents); err != nil {
+				return perrors.WithMessage(err, "invalid ignored_intents")
+			}
+		}
+	}
+
+	if required < 0 {
+		// slot mappings at the root level
+		for i := range keys {
+			if keys[i] == "ignored_intents" {
+				continue
+			}
+			if err = f.addRequiredSlot(keys[i], values[i]); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	if value := bytes.TrimSpace(values[required]); len(value) > 0 && value[0] == '[' {
+		return perrors.WithMessage(json.Unmarshal(value, &f.RequiredSlots), "invalid required_slots")
+	}
+
+	keys, values, err = unmarshalOrderedObject(values[required])
+	if err != nil {
+		return perrors.WithMessage(err, "invalid required_slots")
+	}
+	for i := range keys {
+		if err = f.addRequiredSlot(keys[i], values[i]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// addRequiredSlot adds the slot to the required slots of the form, with the
+// serialized slot mappings.
+func (f *FormDescription) addRequiredSlot(slot string, data json.RawMessage) error {
+	f.RequiredSlots = append(f.RequiredSlots, slot)
+
+	var mappings []SlotMapping
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return perrors.WithMessagef(err, "invalid mappings of slot [%s]", slot)
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+	if f.SlotMappings == nil {
+		f.SlotMappings = make(map[string][]SlotMapping)
+	}
+	f.SlotMappings[slot] = mappings
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// The required slots are serialized as a list, unless the form holds slot
+// mappings.
+func (f FormDescription) MarshalJSON() ([]byte, error) {
+	o := newJSONObject(64)
+	if len(f.SlotMappings) == 0 {
+		required := f.RequiredSlots
+		if required == nil {
+			required = []string{}
+		}
+		o.Value("required_slots", required)
+	} else {
+		slots := newJSONObject(128)
+		for _, slot := range f.RequiredSlots {
+			mappings := f.SlotMappings[slot]
+			if mappings == nil {
+				mappings = []SlotMapping{}
+			}
+			slots.Value(slot, mappings)
+		}
+		data, err := slots.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		o.Raw("required_slots", data)
+	}
+	if len(f.IgnoredIntents) > 0 {
+		o.Value("ignored_intents", f.IgnoredIntents)
+	}
+	return o.Bytes()
+}
+
+// unmarshalNamed unmarshals either a string, or a single-key object. The
+// properties are nil for strings, and for objects with a null value.
+func unmarshalNamed(data []byte) (name string, props json.RawMessage, err error) {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '"' {
+		err = json.Unmarshal(data, &name)
+		return
+	}
+
+	var obj map[string]json.RawMessage
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return
+	}
+	if len(obj) != 1 {
+		err = perrors.Errorf("expected a name or an object with a single key, got %d keys", len(obj))
+		return
+	}
+	for key := range obj {
+		name, props = key, obj[key]
+	}
+	if string(props) == "null" {
+		props = nil
+	}
+	return
+}
+
+// marshalNamed marshals either the name as a string, or a single-key object
+// holding the properties.
+func marshalNamed(name string, props interface{}, nameOnly bool) ([]byte, error) {
+	if nameOnly {
+		return json.Marshal(name)
+	}
+
+	o := newJSONObject(64)
+	o.Value(name, props)
+	return o.Bytes()
+}
+
+// unmarshalOrderedObject unmarshals a JSON object, keeping the order of its
+// keys.
+func unmarshalOrderedObject(data []byte) (keys []string, values []json.RawMessage, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		err = perrors.Errorf("expected an object, got %v", tok)
+		return
+	}
+
+	for dec.More() {
+		if tok, err = dec.Token(); err != nil {
+			return
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return
+		}
+		keys = append(keys, tok.(string))
+		values = append(values, value)
+	}
+	return
 }
@@ -0,0 +1,353 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// DomainError is returned by LoadDomain and Domain.Validate when the domain
+// is inconsistent.
+type DomainError struct {
+	// Issues holds a description of every issue found in the domain.
+	Issues []string
+}
+
+// ensure interface
+var _ error = (*DomainError)(nil)
+
+// Error implements builtin.error.
+func (e *DomainError) Error() string {
+	return fmt.Sprintf("invalid domain: %s", strings.Join(e.Issues, "; "))
+}
+
+// domainKeys holds the top-level keys which mark a domain file, as checked by
+// Rasa's is_domain_file. Files in a domain directory without any of these keys
+// are skipped. Keys such as "version" are also present in training data
+// files, so they do not mark a domain file.
+var domainKeys = []string{
+	"session_config",
+	"intents",
+	"entities",
+	"slots",
+	"responses",
+	"actions",
+	"forms",
+	"e2e_actions",
+}
+
+// LoadDomain loads the domain from the provided YAML files or directories,
+// and validates the result.
+//
+// Directories are searched recursively for `.yml` and `.yaml` files which
+// contain domain keys, in lexical order. The domains are merged the same way
+// as Rasa merges them: lists such as intents and actions are combined
+// without duplicates, and for slots, responses, and forms the definition
+// which is loaded first takes precedence. The configuration and session
+// configuration are taken from the first file that defines them, and default
+// to those of Rasa.
+//
+// If the merged domain is invalid, it is returned along with a *DomainError
+// describing the issues. Other errors are returned with a nil Domain.
+func LoadDomain(paths ...string) (domain *Domain, err error) {
+	var files []string
+	for _, path := range paths {
+		var found []string
+		if found, err = domainFiles(path); err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	domain = &Domain{
+		Config:        DomainConfig{StoreEntitiesAsSlots: true},
+		SessionConfig: SessionConfig{SessionExpirationTime: 60, CarryOverSlots: true},
+	}
+	var issues []string
+	hasConfig, hasSessionConfig := false, false
+	for _, file := range files {
+		var part *Domain
+		var keys map[string]bool
+		if part, keys, err = loadDomainFile(file); err != nil {
+			return nil, perrors.WithMessagef(err, "unable to load domain file [%s]", file)
+		}
+
+		if keys["config"] && !hasConfig {
+			domain.Config, hasConfig = part.Config, true
+		}
+		if keys["session_config"] && !hasSessionConfig {
+			domain.SessionConfig, hasSessionConfig = part.SessionConfig, true
+		}
+		for name := range part.Responses {
+			if _, exists := domain.Responses[name]; exists {
+				issues = append(issues, fmt.Sprintf("response [%s] in [%s] is already defined", name, file))
+			}
+		}
+		domain.merge(part)
+	}
+
+	if err := domain.Validate(); err != nil {
+		issues = append(issues, err.(*DomainError).Issues...)
+	}
+	if len(issues) > 0 {
+		return domain, &DomainError{Issues: issues}
+	}
+	return domain, nil
+}
+
+// domainFiles returns the domain files at path. If path is a file, it is
+// returned as is.
+func domainFiles(path string) (files []string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(file); ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+
+		ok, err := isDomainFile(file)
+		if ok {
+			files = append(files, file)
+		}
+		return err
+	})
+	return
+}
+
+// isDomainFile returns whether the YAML file holds any domain keys.
+func isDomainFile(file string) (bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	var content yaml.MapSlice
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		// not a YAML mapping, e.g. a list of NLU examples
+		return false, nil
+	}
+	for i := range content {
+		if key, ok := content[i].Key.(string); ok && sliceContains(domainKeys, key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// loadDomainFile loads a single domain file. The returned keys hold the
+// top-level keys present in the file.
+func loadDomainFile(file string) (domain *Domain, keys map[string]bool, err error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+
+	var content yaml.MapSlice
+	if err = yaml.Unmarshal(data, &content); err != nil {
+		return
+	}
+
+	keys = make(map[string]bool, len(content))
+	for i := range content {
+		key := fmt.Sprint(content[i].Key)
+		keys[key] = true
+
+		// an unquoted version is parsed as a number
+		if key == "version" {
+			if _, ok := content[i].Value.(string); !ok && content[i].Value != nil {
+				content[i].Value = fmt.Sprint(content[i].Value)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = writeYAMLAsJSON(&buf, content); err != nil {
+		return
+	}
+
+	domain = new(Domain)
+	err = json.Unmarshal(buf.Bytes(), domain)
+	return
+}
+
+// writeYAMLAsJSON writes a value decoded by yaml.v2 as JSON, keeping the order
+// of the keys in mappings.
+func writeYAMLAsJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(appendJSONString(nil, fmt.Sprint(v[i].Key)))
+			buf.WriteByte(':')
+			if err := writeYAMLAsJSON(buf, v[i].Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		values := make(map[string]interface{}, len(v))
+		for key := range v {
+			keys = append(keys, fmt.Sprint(key))
+			values[fmt.Sprint(key)] = v[key]
+		}
+		sort.Strings(keys)
+
+		slice := make(yaml.MapSlice, len(keys))
+		for i := range keys {
+			slice[i] = yaml.MapItem{Key: keys[i], Value: values[keys[i]]}
+		}
+		return writeYAMLAsJSON(buf, slice)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(buf, v[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// merge adds the contents of other to d. Existing definitions take
+// precedence.
+func (d *Domain) merge(other *Domain) {
+	if d.Version == "" {
+		d.Version = other.Version
+	}
+
+	for _, intent := range other.Intents {
+		if !d.HasIntent(intent.Name) {
+			d.Intents = append(d.Intents, intent)
+		}
+	}
+	for _, entity := range other.Entities {
+		if !sliceContains(d.EntityNames(), entity.Name) {
+			d.Entities = append(d.Entities, entity)
+		}
+	}
+	d.Actions = mergeStrings(d.Actions, other.Actions)
+	d.E2EActions = mergeStrings(d.E2EActions, other.E2EActions)
+
+	for name := range other.Slots {
+		if _, exists := d.Slots[name]; !exists {
+			if d.Slots == nil {
+				d.Slots = make(map[string]SlotDescription)
+			}
+			d.Slots[name] = other.Slots[name]
+		}
+	}
+	for name := range other.Responses {
+		if _, exists := d.Responses[name]; !exists {
+			if d.Responses == nil {
+				d.Responses = make(map[string][]ResponseDescription)
+			}
+			d.Responses[name] = other.Responses[name]
+		}
+	}
+	for name := range other.Forms {
+		if _, exists := d.Forms[name]; !exists {
+			if d.Forms == nil {
+				d.Forms = make(map[string]FormDescription)
+			}
+			d.Forms[name] = other.Forms[name]
+		}
+	}
+}
+
+// mergeStrings appends the values in other to values, skipping duplicates.
+func mergeStrings(values, other []string) []string {
+	for _, value := range other {
+		if !sliceContains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// responsePlaceholder matches the `{slot}` placeholders in response texts.
+var responsePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Validate checks the consistency of the domain. A *DomainError is returned
+// if the forms require slots, or the responses reference slots, which are not
+// defined in the domain.
+func (d *Domain) Validate() error {
+	var issues []string
+
+	forms := make([]string, 0, len(d.Forms))
+	for name := range d.Forms {
+		forms = append(forms, name)
+	}
+	sort.Strings(forms)
+	for _, form := range forms {
+		for _, slot := range d.Forms[form].RequiredSlots {
+			if _, ok := d.Slots[slot]; !ok {
+				issues = append(issues, fmt.Sprintf("form [%s] requires undefined slot [%s]", form, slot))
+			}
+		}
+	}
+
+	responses := make([]string, 0, len(d.Responses))
+	for name := range d.Responses {
+		responses = append(responses, name)
+	}
+	sort.Strings(responses)
+	for _, response := range responses {
+		var missing []string
+		for _, variation := range d.Responses[response] {
+			for _, match := range responsePlaceholder.FindAllStringSubmatch(variation.Text, -1) {
+				if _, ok := d.Slots[match[1]]; !ok && !sliceContains(missing, match[1]) {
+					missing = append(missing, match[1])
+				}
+			}
+		}
+		for _, slot := range missing {
+			issues = append(issues, fmt.Sprintf("response [%s] references undefined slot [%s]", response, slot))
+		}
+	}
+
+	if len(issues) > 0 {
+		return &DomainError{Issues: issues}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadDomain
+func TestLoadDomain(t *testing.T) {
+	project := filepath.Join("testdata", "domain", "project")
+
+	t.Run("directory", func(t *testing.T) {
+		domain, err := LoadDomain(filepath.Join(project, "domain"))
+		require.NoError(t, err)
+
+		require.Equal(t, "3.1", domain.Version)
+		require.Equal(t, DomainConfig{StoreEntitiesAsSlots: true}, domain.Config)
+		require.Equal(t, SessionConfig{SessionExpirationTime: 30, CarryOverSlots: false}, domain.SessionConfig)
+		require.Equal(t, []string{"greet", "inform", "book"}, domain.IntentNames())
+		require.Equal(t, []string{"city"}, domain.EntityNames())
+		require.Equal(t, []string{"action_search", "validate_booking_form", "action_joke"}, domain.Actions)
+
+		inform, _ := domain.Intent("inform")
+		require.Equal(t, []string{"city"}, inform.UsedEntities)
+
+		// the first definition of a slot takes precedence
+		city, _ := domain.Slot("city")
+		require.Equal(t, "text", city.Kind())
+		date, _ := domain.Slot("date")
+		require.Equal(t, []MappingCondition{{ActiveLoop: "booking_form", RequestedSlot: "date"}}, date.Mappings[0].Conditions)
+
+		require.Equal(t, []string{"city", "date"}, domain.FormRequiredSlots("booking_form"))
+		greet, _ := domain.Response("utter_greet")
+		require.Len(t, greet, 2)
+		require.Equal(t, "slack", greet[1].Channel)
+		require.True(t, domain.HasAction("utter_confirm"))
+	})
+
+	t.Run("files", func(t *testing.T) {
+		domain, err := LoadDomain(filepath.Join(project, "domain", "booking.yml"))
+		require.NoError(t, err)
+		city, _ := domain.Slot("city")
+		require.Equal(t, "categorical", city.Kind())
+		require.Equal(t, SessionConfig{SessionExpirationTime: 60, CarryOverSlots: true}, domain.SessionConfig)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		// the nlu data in the project directory is skipped
+		domain, err := LoadDomain(project)
+		require.Error(t, err)
+		require.NotNil(t, domain)
+		require.False(t, domain.Config.StoreEntitiesAsSlots)
+
+		var derr *DomainError
+		require.True(t, errors.As(err, &derr))
+		require.Equal(t, []string{
+			"response [utter_greet] in [" + filepath.Join(project, "override.yml") + "] is already defined",
+			"form [survey_form] requires undefined slot [rating]",
+			"response [utter_thanks] references undefined slot [rating]",
+		}, derr.Issues)
+
+		// the first definition of a response takes precedence
+		greet, _ := domain.Response("utter_greet")
+		require.Equal(t, "Hello!", greet[0].Text)
+	})
+
+	t.Run("domain files", func(t *testing.T) {
+		cases := []struct {
+			file   string
+			expect bool
+		}{
+			{file: filepath.Join(project, "domain", "base.yml"), expect: true},
+			{file: filepath.Join(project, "override.yml"), expect: true},
+			// training data files also start with a version
+			{file: filepath.Join(project, "data", "nlu.yml"), expect: false},
+		}
+
+		for i := range cases {
+			isDomain, err := isDomainFile(cases[i].file)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, cases[i].expect, isDomain, "failed on %d", i)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := LoadDomain(filepath.Join(project, "missing.yml"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestDomain unmarshals the domain fixture with the provided name.
+func loadTestDomain(t *testing.T, name string) *Domain {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "domain", name))
+	require.NoError(t, err)
+
+	var domain Domain
+	require.NoError(t, json.Unmarshal(data, &domain))
+	return &domain
+}
+
+// TestDomain
+func TestDomain(t *testing.T) {
+	t.Run("3.x", func(t *testing.T) {
+		domain := loadTestDomain(t, "3.x.json")
+
+		require.Equal(t, "3.1", domain.Version)
+		require.Equal(t, SessionConfig{SessionExpirationTime: 60, CarryOverSlots: true}, domain.SessionConfig)
+		require.Equal(t, []string{"greet", "inform", "bye", "chitchat", "deny", "EXTERNAL_reminder"}, domain.IntentNames())
+		require.Equal(t, []string{"date", "city"}, domain.EntityNames())
+		require.Equal(t, []string{"departure", "destination"}, domain.Entities[1].Roles)
+
+		intents := []struct {
+			name   string
+			entity string
+			uses   bool
+		}{
+			{"greet", "city", true},
+			{"inform", "city", true},
+			{"inform", "other", false},
+			{"bye", "city", false},
+			{"chitchat", "city", true},
+			{"deny", "city", false},
+			{"deny", "date", true},
+		}
+		for i := range intents {
+			intent, ok := domain.Intent(intents[i].name)
+			require.Truef(t, ok, "failed on %d", i)
+			require.Equalf(t, intents[i].uses, intent.UsesEntity(intents[i].entity), "failed on %d", i)
+		}
+		chitchat, _ := domain.Intent("chitchat")
+		require.True(t, chitchat.IsRetrievalIntent)
+		reminder, _ := domain.Intent("EXTERNAL_reminder")
+		require.Equal(t, "action_remind", reminder.Triggers)
+
+		city, ok := domain.Slot("city")
+		require.True(t, ok)
+		require.Equal(t, "text", city.Kind())
+		require.True(t, city.Influences())
+		require.Equal(t, []SlotMapping{{
+			Type:       "from_entity",
+			Entity:     "city",
+			Role:       "destination",
+			Conditions: []MappingCondition{{ActiveLoop: "booking_form", RequestedSlot: "city"}},
+		}}, city.Mappings)
+
+		travellers, _ := domain.Slot("travellers")
+		require.Equal(t, "float", travellers.Kind())
+		require.False(t, travellers.Influences())
+		require.Equal(t, 1.0, *travellers.MinValue)
+		require.Equal(t, 8.0, *travellers.MaxValue)
+		require.Equal(t, StringList{"inform"}, travellers.Mappings[0].Intent)
+		require.Equal(t, StringList{"deny", "bye"}, travellers.Mappings[0].NotIntent)
+
+		class, _ := domain.Slot("class")
+		require.Equal(t, "categorical", class.Kind())
+		require.Equal(t, []string{"economy", "business"}, class.Values)
+		require.Equal(t, Slots{"city": nil, "travellers": 1.0, "class": "economy"}, domain.InitialSlots())
+
+		_, ok = domain.Slot("missing")
+		require.False(t, ok)
+
+		greet, ok := domain.Response("utter_greet")
+		require.True(t, ok)
+		require.Len(t, greet, 2)
+		require.Equal(t, []ResponseCondition{{Type: "slot", Name: "logged_in", Value: true}}, greet[1].Condition)
+
+		ask, _ := domain.Response("utter_ask_city")
+		require.Equal(t, "vertical", ask[0].ButtonType)
+		require.Equal(t, []Button{{Title: "Paris", Payload: `/inform{"city":"Paris"}`}}, ask[0].Buttons)
+		require.Equal(t, "slack", ask[1].Channel)
+		require.NotNil(t, ask[1].Custom["blocks"])
+
+		require.Equal(t, []string{"city", "travellers"}, domain.FormRequiredSlots("booking_form"))
+		require.Nil(t, domain.FormRequiredSlots("missing"))
+		form, _ := domain.Form("booking_form")
+		require.Equal(t, StringList{"chitchat"}, form.IgnoredIntents)
+		require.Equal(t, []string{"Bye!"}, domain.E2EActions)
+
+		for _, action := range []string{"action_extract_class", "utter_greet", "booking_form"} {
+			require.Truef(t, domain.HasAction(action), "failed on %s", action)
+		}
+		require.False(t, domain.HasAction("action_missing"))
+		require.True(t, domain.HasIntent("greet"))
+		require.False(t, domain.HasIntent("missing"))
+	})
+
+	t.Run("2.x", func(t *testing.T) {
+		domain := loadTestDomain(t, "2.x.json")
+
+		require.Equal(t, []string{"greet", "inform"}, domain.IntentNames())
+		city, _ := domain.Slot("city")
+		require.True(t, city.AutoFill)
+		requested, _ := domain.Slot("requested_slot")
+		require.Equal(t, "unfeaturized", requested.Kind())
+		require.False(t, requested.Influences())
+
+		require.Equal(t, []string{"city", "date"}, domain.FormRequiredSlots("booking_form"))
+		form, _ := domain.Form("booking_form")
+		require.Equal(t, []SlotMapping{{Type: "from_entity", Entity: "city"}}, form.SlotMappings["city"])
+		require.Equal(t, []string{"name"}, domain.FormRequiredSlots("legacy_form"))
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		var domain Domain
+		require.NoError(t, json.Unmarshal([]byte(`{"actions":["action_a",{"action_b":{"send_domain":true}}]}`), &domain))
+		require.Equal(t, []string{"action_a", "action_b"}, domain.Actions)
+
+		require.Error(t, json.Unmarshal([]byte(`{"actions":[{"action_a":{},"action_b":{}}]}`), &domain))
+		require.Error(t, json.Unmarshal([]byte(`{"intents":[1]}`), &domain))
+	})
+
+	t.Run("JSON round trip", func(t *testing.T) {
+		for _, name := range []string{"2.x.json", "3.x.json"} {
+			domain := loadTestDomain(t, name)
+
+			ser, err := json.Marshal(domain)
+			require.NoErrorf(t, err, "failed on %s", name)
+
+			var result Domain
+			require.NoErrorf(t, json.Unmarshal(ser, &result), "failed on %s", name)
+			require.Equalf(t, domain, &result, "failed on %s", name)
+		}
+	})
+}
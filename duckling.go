@@ -0,0 +1,480 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	perrors "github.com/pkg/errors"
+)
+
+// Dimensions extracted by Duckling, which the DucklingEntityExtractor uses
+// as the names of the entities.
+const (
+	DimensionAmountOfMoney = "amount-of-money"
+	DimensionCreditCard    = "credit-card-number"
+	DimensionDistance      = "distance"
+	DimensionDuration      = "duration"
+	DimensionEmail         = "email"
+	DimensionNumber        = "number"
+	DimensionOrdinal       = "ordinal"
+	DimensionPhoneNumber   = "phone-number"
+	DimensionQuantity      = "quantity"
+	DimensionTemperature   = "temperature"
+	DimensionTime          = "time"
+	DimensionURL           = "url"
+	DimensionVolume        = "volume"
+)
+
+// ErrEntityNotFound is the cause of an EntityError returned for entities
+// which are not present in the latest message.
+var ErrEntityNotFound = perrors.New("entity not found")
+
+// EntityError is returned by the typed entity accessors of Entity and
+// Tracker when the value of an entity cannot be read as the requested type.
+type EntityError struct {
+	// Entity holds the name of the entity.
+	Entity string
+
+	// Value holds the value of the entity.
+	Value interface{}
+
+	// Target describes the requested type.
+	Target string
+
+	// Cause holds the underlying error.
+	Cause error
+}
+
+// ensure interface
+var _ error = (*EntityError)(nil)
+
+// Error implements builtin.error.
+func (e *EntityError) Error() string {
+	if e.Cause == ErrEntityNotFound {
+		return fmt.Sprintf("entity [%s] not found", e.Entity)
+	}
+	return fmt.Sprintf(
+		"unable to read entity [%s] with value [%v] as %s: %s",
+		e.Entity,
+		e.Value,
+		e.Target,
+		e.Cause.Error(),
+	)
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *EntityError) Unwrap() error {
+	return e.Cause
+}
+
+// Grain is the granularity of a time extracted by Duckling.
+type Grain string
+
+// Grains reported by Duckling.
+const (
+	GrainSecond  = Grain("second")
+	GrainMinute  = Grain("minute")
+	GrainHour    = Grain("hour")
+	GrainDay     = Grain("day")
+	GrainWeek    = Grain("week")
+	GrainMonth   = Grain("month")
+	GrainQuarter = Grain("quarter")
+	GrainYear    = Grain("year")
+)
+
+// Add returns t advanced by n units of the grain. Unknown grains are treated
+// as seconds.
+func (g Grain) Add(t time.Time, n int) time.Time {
+	switch g {
+	case GrainMinute:
+		return t.Add(time.Duration(n) * time.Minute)
+	case GrainHour:
+		return t.Add(time.Duration(n) * time.Hour)
+	case GrainDay:
+		return t.AddDate(0, 0, n)
+	case GrainWeek:
+		return t.AddDate(0, 0, 7*n)
+	case GrainMonth:
+		return t.AddDate(0, n, 0)
+	case GrainQuarter:
+		return t.AddDate(0, 3*n, 0)
+	case GrainYear:
+		return t.AddDate(n, 0, 0)
+	}
+	return t.Add(time.Duration(n) * time.Second)
+}
+
+// EntityTime holds a point in time extracted by Duckling, such as "tomorrow"
+// or "at 5pm".
+type EntityTime struct {
+	// Time holds the start of the time, in the timezone reported by Duckling.
+	Time time.Time
+
+	// Grain holds the granularity of the time. For example, "tomorrow" has
+	// the grain day, and "tomorrow at 5pm" the grain hour.
+	Grain Grain
+}
+
+// End returns the end of the time according to its grain, which is exclusive.
+// For example, the end of "tomorrow" is midnight of the day after tomorrow.
+func (t EntityTime) End() time.Time {
+	return t.Grain.Add(t.Time, 1)
+}
+
+// EntityInterval holds an interval of time extracted by Duckling, such as
+// "from 3 to 5pm" or "after tomorrow". Open intervals have no From or To.
+type EntityInterval struct {
+	// From holds the start of the interval, or nil.
+	From *EntityTime
+
+	// To holds the end of the interval, or nil. As reported by Duckling, the
+	// end is exclusive, so "from 3 to 5pm" ends at 6pm.
+	To *EntityTime
+}
+
+// EntityAmount holds a quantity with its unit, such as an amount of money
+// ("20 dollars"), a distance ("5 km"), or a temperature ("20 degrees").
+type EntityAmount struct {
+	// Value holds the numeric value of the amount.
+	Value float64
+
+	// Unit holds the unit of the amount, such as "USD", "kilometre" or
+	// "celsius". For amounts of money with an ambiguous currency symbol,
+	// Duckling reports the symbol, such as "$". It is empty if the unit is
+	// not specified.
+	Unit string
+}
+
+// EntityAmountRange holds a range of quantities extracted by Duckling, such
+// as "between 10 and 20 dollars" or "under 5 km". Open ranges have no From or
+// To.
+type EntityAmountRange struct {
+	// From holds the lower bound of the range, or nil.
+	From *EntityAmount
+
+	// To holds the upper bound of the range, or nil.
+	To *EntityAmount
+}
+
+// EntityDuration holds a duration extracted by Duckling, such as "2 hours".
+type EntityDuration struct {
+	// Value holds the number of units of the duration.
+	Value float64
+
+	// Unit holds the unit of the duration.
+	Unit Grain
+
+	// Duration holds the normalized duration. Durations in months or years
+	// are approximated by Duckling.
+	Duration time.Duration
+}
+
+// ducklingValue holds the additional information of an entity extracted by
+// Duckling.
+type ducklingValue struct {
+	Type       string         `json:"type"`
+	Value      interface{}    `json:"value"`
+	Grain      Grain          `json:"grain"`
+	Unit       string         `json:"unit"`
+	From       *ducklingValue `json:"from"`
+	To         *ducklingValue `json:"to"`
+	Normalized *ducklingValue `json:"normalized"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Scalars are decoded as the
+// value, as the bounds of intervals are plain values in Entity.Value.
+func (v *ducklingValue) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '{' {
+		return json.Unmarshal(data, &v.Value)
+	}
+
+	type plain ducklingValue
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+// Duckling value types.
+const (
+	ducklingTypeValue    = "value"
+	ducklingTypeInterval = "interval"
+)
+
+// duckling decodes the additional information of the entity. Entities without
+// additional information, such as entities set from slots, are decoded from
+// their value.
+func (e *Entity) duckling() (*ducklingValue, error) {
+	src := e.AdditionalInfo
+	if src == nil {
+		src = e.Value
+	}
+
+	switch val := src.(type) {
+	case nil:
+		return nil, perrors.New("entity has no value")
+	case map[string]interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		var v ducklingValue
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		if v.Type == "" {
+			if v.From != nil || v.To != nil {
+				v.Type = ducklingTypeInterval
+			} else {
+				v.Type = ducklingTypeValue
+			}
+		}
+		return &v, nil
+	}
+	return &ducklingValue{Type: ducklingTypeValue, Value: src}, nil
+}
+
+// entityAs decodes the entity, and passes it to fn for conversion. Errors are
+// wrapped in an EntityError.
+func (e *Entity) entityAs(target string, fn func(v *ducklingValue) error) error {
+	v, err := e.duckling()
+	if err == nil {
+		err = fn(v)
+	}
+	if err != nil {
+		return &EntityError{Entity: e.Entity, Value: e.Value, Target: target, Cause: err}
+	}
+	return nil
+}
+
+// Time returns the value of the entity as a point in time. An error is
+// returned for intervals.
+func (e *Entity) Time() (val EntityTime, err error) {
+	err = e.entityAs("time", func(v *ducklingValue) error {
+		if v.Type == ducklingTypeInterval {
+			return perrors.New("entity is an interval")
+		}
+		val, err = v.time()
+		return err
+	})
+	return
+}
+
+// Interval returns the value of the entity as an interval of time. A point
+// in time is returned as the interval covering its grain, so "tomorrow" is
+// returned as the interval from midnight to midnight.
+func (e *Entity) Interval() (val EntityInterval, err error) {
+	err = e.entityAs("time interval", func(v *ducklingValue) error {
+		if v.Type != ducklingTypeInterval {
+			t, err := v.time()
+			if err != nil {
+				return err
+			}
+			val.From = &t
+			val.To = &EntityTime{Time: t.End(), Grain: t.Grain}
+			return nil
+		}
+
+		val = EntityInterval{}
+		if v.From != nil {
+			t, err := v.From.time()
+			if err != nil {
+				return perrors.WithMessage(err, "invalid start")
+			}
+			val.From = &t
+		}
+		if v.To != nil {
+			t, err := v.To.time()
+			if err != nil {
+				return perrors.WithMessage(err, "invalid end")
+			}
+			val.To = &t
+		}
+		return nil
+	})
+	return
+}
+
+// Amount returns the value of the entity as an amount with its unit, which
+// applies to amounts of money, distances, temperatures, volumes, and plain
+// numbers. An error is returned for ranges.
+func (e *Entity) Amount() (val EntityAmount, err error) {
+	err = e.entityAs("amount", func(v *ducklingValue) error {
+		if v.Type == ducklingTypeInterval {
+			return perrors.New("entity is a range")
+		}
+		val, err = v.amount()
+		return err
+	})
+	return
+}
+
+// AmountRange returns the value of the entity as a range of amounts. A
+// single amount is returned as the range from and to the amount.
+func (e *Entity) AmountRange() (val EntityAmountRange, err error) {
+	err = e.entityAs("amount range", func(v *ducklingValue) error {
+		if v.Type != ducklingTypeInterval {
+			a, err := v.amount()
+			if err != nil {
+				return err
+			}
+			to := a
+			val.From, val.To = &a, &to
+			return nil
+		}
+
+		val = EntityAmountRange{}
+		if v.From != nil {
+			a, err := v.From.amount()
+			if err != nil {
+				return perrors.WithMessage(err, "invalid lower bound")
+			}
+			val.From = &a
+		}
+		if v.To != nil {
+			a, err := v.To.amount()
+			if err != nil {
+				return perrors.WithMessage(err, "invalid upper bound")
+			}
+			val.To = &a
+		}
+		return nil
+	})
+	return
+}
+
+// Duration returns the value of the entity as a duration.
+func (e *Entity) Duration() (val EntityDuration, err error) {
+	err = e.entityAs("duration", func(v *ducklingValue) error {
+		a, err := v.amount()
+		if err != nil {
+			return err
+		}
+		val = EntityDuration{Value: a.Value, Unit: Grain(a.Unit)}
+
+		if v.Normalized != nil {
+			seconds, err := coerceFloat(v.Normalized.Value)
+			if err != nil {
+				return perrors.WithMessage(err, "invalid normalized value")
+			}
+			val.Duration = time.Duration(seconds * float64(time.Second))
+		} else if a.Unit != "" {
+			start := time.Time{}
+			val.Duration = val.Unit.Add(start, int(a.Value)).Sub(start)
+		}
+		return nil
+	})
+	return
+}
+
+// time converts the value into an EntityTime.
+func (v *ducklingValue) time() (EntityTime, error) {
+	str, err := coerceString(v.Value)
+	if err != nil {
+		return EntityTime{}, err
+	}
+	t, err := parseISOTime(str)
+	if err != nil {
+		return EntityTime{}, perrors.Errorf("invalid time [%s]", str)
+	}
+	if t.IsZero() {
+		return EntityTime{}, perrors.New("empty time")
+	}
+	return EntityTime{Time: t, Grain: v.Grain}, nil
+}
+
+// amount converts the value into an EntityAmount.
+func (v *ducklingValue) amount() (EntityAmount, error) {
+	f, err := coerceFloat(v.Value)
+	if err != nil {
+		return EntityAmount{}, err
+	}
+	return EntityAmount{Value: f, Unit: v.Unit}, nil
+}
+
+// latestEntity returns the first entity in the latest message matching the
+// name, role and group, or nil.
+func (t *Tracker) latestEntity(entity, role, group string) *Entity {
+	if t.LatestMessage == nil {
+		return nil
+	}
+
+	entities := t.LatestMessage.Entities
+	for i := range entities {
+		if entities[i].Entity != entity {
+			continue
+		}
+		if role != "" && role != entities[i].Role {
+			continue
+		}
+		if group != "" && group != entities[i].Group {
+			continue
+		}
+		return &entities[i]
+	}
+	return nil
+}
+
+// entityAs looks up the entity in the latest message, and passes it to fn for
+// conversion.
+func (t *Tracker) entityAs(entity, role, group string, fn func(e *Entity) error) error {
+	e := t.latestEntity(entity, role, group)
+	if e == nil {
+		return &EntityError{Entity: entity, Cause: ErrEntityNotFound}
+	}
+	return fn(e)
+}
+
+// EntityTime returns the value of the entity in the latest message as a
+// point in time. See Entity.Time.
+func (t *Tracker) EntityTime(entity, role, group string) (val EntityTime, err error) {
+	err = t.entityAs(entity, role, group, func(e *Entity) (err error) {
+		val, err = e.Time()
+		return
+	})
+	return
+}
+
+// EntityInterval returns the value of the entity in the latest message as an
+// interval of time. See Entity.Interval.
+func (t *Tracker) EntityInterval(entity, role, group string) (val EntityInterval, err error) {
+	err = t.entityAs(entity, role, group, func(e *Entity) (err error) {
+		val, err = e.Interval()
+		return
+	})
+	return
+}
+
+// EntityAmount returns the value of the entity in the latest message as an
+// amount. See Entity.Amount.
+func (t *Tracker) EntityAmount(entity, role, group string) (val EntityAmount, err error) {
+	err = t.entityAs(entity, role, group, func(e *Entity) (err error) {
+		val, err = e.Amount()
+		return
+	})
+	return
+}
+
+// EntityAmountRange returns the value of the entity in the latest message as
+// a range of amounts. See Entity.AmountRange.
+func (t *Tracker) EntityAmountRange(entity, role, group string) (val EntityAmountRange, err error) {
+	err = t.entityAs(entity, role, group, func(e *Entity) (err error) {
+		val, err = e.AmountRange()
+		return
+	})
+	return
+}
+
+// EntityDuration returns the value of the entity in the latest message as a
+// duration. See Entity.Duration.
+func (t *Tracker) EntityDuration(entity, role, group string) (val EntityDuration, err error) {
+	err = t.entityAs(entity, role, group, func(e *Entity) (err error) {
+		val, err = e.Duration()
+		return
+	})
+	return
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Entities extracted by Rasa's DucklingEntityExtractor.
+const (
+	ducklingTomorrow = `{
+		"start": 0, "end": 8, "text": "tomorrow", "value": "2020-10-21T00:00:00.000-07:00",
+		"confidence": 1.0, "entity": "time", "extractor": "DucklingEntityExtractor",
+		"additional_info": {
+			"values": [{"value": "2020-10-21T00:00:00.000-07:00", "grain": "day", "type": "value"}],
+			"value": "2020-10-21T00:00:00.000-07:00", "grain": "day", "type": "value"
+		}
+	}`
+	ducklingInterval = `{
+		"start": 0, "end": 18, "text": "from 3 to 5pm", "entity": "time", "extractor": "DucklingEntityExtractor",
+		"value": {"to": "2020-10-20T18:00:00.000-07:00", "from": "2020-10-20T15:00:00.000-07:00"},
+		"additional_info": {
+			"values": [{
+				"to": {"value": "2020-10-20T18:00:00.000-07:00", "grain": "hour"},
+				"from": {"value": "2020-10-20T15:00:00.000-07:00", "grain": "hour"},
+				"type": "interval"
+			}],
+			"to": {"value": "2020-10-20T18:00:00.000-07:00", "grain": "hour"},
+			"from": {"value": "2020-10-20T15:00:00.000-07:00", "grain": "hour"},
+			"type": "interval"
+		}
+	}`
+	ducklingAfter = `{
+		"start": 0, "end": 13, "text": "after 5pm", "entity": "time", "extractor": "DucklingEntityExtractor",
+		"value": {"to": null, "from": "2020-10-20T17:00:00.000-07:00"},
+		"additional_info": {
+			"from": {"value": "2020-10-20T17:00:00.000-07:00", "grain": "hour"},
+			"type": "interval"
+		}
+	}`
+	ducklingMoney = `{
+		"start": 0, "end": 10, "text": "20 dollars", "value": 20, "confidence": 1.0,
+		"entity": "amount-of-money", "extractor": "DucklingEntityExtractor",
+		"additional_info": {"value": 20, "type": "value", "unit": "USD"}
+	}`
+	ducklingMoneyRange = `{
+		"start": 0, "end": 24, "text": "between 10 and 20 euros", "value": {"to": 20, "from": 10},
+		"entity": "amount-of-money", "extractor": "DucklingEntityExtractor",
+		"additional_info": {
+			"to": {"value": 20, "unit": "EUR"}, "from": {"value": 10, "unit": "EUR"}, "type": "interval"
+		}
+	}`
+	ducklingDistance = `{
+		"start": 0, "end": 4, "text": "5 km", "value": 5, "entity": "distance",
+		"extractor": "DucklingEntityExtractor",
+		"additional_info": {"value": 5, "type": "value", "unit": "kilometre"}
+	}`
+	ducklingDuration = `{
+		"start": 0, "end": 7, "text": "2 hours", "value": 2, "entity": "duration",
+		"extractor": "DucklingEntityExtractor",
+		"additional_info": {
+			"value": 2, "hour": 2, "type": "value", "unit": "hour",
+			"normalized": {"value": 7200, "unit": "second"}
+		}
+	}`
+)
+
+// mustEntity unmarshals the entity.
+func mustEntity(t *testing.T, data string) *Entity {
+	var e Entity
+	require.NoError(t, json.Unmarshal([]byte(data), &e))
+	return &e
+}
+
+// TestEntityTime
+func TestEntityTime(t *testing.T) {
+	zone := time.FixedZone("", -7*60*60)
+	at := func(hour int) time.Time { return time.Date(2020, 10, 20, hour, 0, 0, 0, zone) }
+
+	t.Run("Time", func(t *testing.T) {
+		cases := []struct {
+			entity string
+			expect EntityTime
+			fail   bool
+		}{
+			{
+				entity: ducklingTomorrow,
+				expect: EntityTime{Time: time.Date(2020, 10, 21, 0, 0, 0, 0, zone), Grain: GrainDay},
+			},
+			{
+				// without additional info
+				entity: `{"entity": "time", "value": "2020-10-20T15:00:00.000-07:00"}`,
+				expect: EntityTime{Time: at(15)},
+			},
+			{entity: ducklingInterval, fail: true},
+			{entity: ducklingMoney, fail: true},
+			{entity: `{"entity": "time", "value": "soon"}`, fail: true},
+		}
+
+		for i := range cases {
+			val, err := mustEntity(t, cases[i].entity).Time()
+			if cases[i].fail {
+				var entityErr *EntityError
+				require.Truef(t, errors.As(err, &entityErr), "failed on %d", i)
+				continue
+			}
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Truef(t, cases[i].expect.Time.Equal(val.Time), "failed on %d", i)
+			require.Equalf(t, cases[i].expect.Grain, val.Grain, "failed on %d", i)
+		}
+
+		val, err := mustEntity(t, ducklingTomorrow).Time()
+		require.NoError(t, err)
+		require.True(t, time.Date(2020, 10, 22, 0, 0, 0, 0, zone).Equal(val.End()))
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		cases := []struct {
+			entity   string
+			from, to time.Time
+		}{
+			{entity: ducklingInterval, from: at(15), to: at(18)},
+			{entity: ducklingAfter, from: at(17)},
+			{
+				// without additional info
+				entity: `{"entity": "time", "value": {"to": "2020-10-20T18:00:00.000-07:00", "from": "2020-10-20T15:00:00.000-07:00"}}`,
+				from:   at(15),
+				to:     at(18),
+			},
+			{
+				entity: ducklingTomorrow,
+				from:   time.Date(2020, 10, 21, 0, 0, 0, 0, zone),
+				to:     time.Date(2020, 10, 22, 0, 0, 0, 0, zone),
+			},
+		}
+
+		for i := range cases {
+			val, err := mustEntity(t, cases[i].entity).Interval()
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Truef(t, cases[i].from.Equal(val.From.Time), "failed on %d", i)
+			if cases[i].to.IsZero() {
+				require.Nilf(t, val.To, "failed on %d", i)
+			} else {
+				require.Truef(t, cases[i].to.Equal(val.To.Time), "failed on %d", i)
+			}
+		}
+	})
+}
+
+// TestEntityAmount
+func TestEntityAmount(t *testing.T) {
+	t.Run("Amount", func(t *testing.T) {
+		cases := []struct {
+			entity string
+			expect EntityAmount
+			fail   bool
+		}{
+			{entity: ducklingMoney, expect: EntityAmount{Value: 20, Unit: "USD"}},
+			{entity: ducklingDistance, expect: EntityAmount{Value: 5, Unit: "kilometre"}},
+			{entity: `{"entity": "number", "value": 3}`, expect: EntityAmount{Value: 3}},
+			{entity: ducklingMoneyRange, fail: true},
+			{entity: ducklingTomorrow, fail: true},
+		}
+
+		for i := range cases {
+			val, err := mustEntity(t, cases[i].entity).Amount()
+			if cases[i].fail {
+				require.Errorf(t, err, "failed on %d", i)
+				continue
+			}
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, cases[i].expect, val, "failed on %d", i)
+		}
+	})
+
+	t.Run("AmountRange", func(t *testing.T) {
+		val, err := mustEntity(t, ducklingMoneyRange).AmountRange()
+		require.NoError(t, err)
+		require.Equal(t, EntityAmountRange{
+			From: &EntityAmount{Value: 10, Unit: "EUR"},
+			To:   &EntityAmount{Value: 20, Unit: "EUR"},
+		}, val)
+
+		val, err = mustEntity(t, ducklingMoney).AmountRange()
+		require.NoError(t, err)
+		require.Equal(t, EntityAmountRange{
+			From: &EntityAmount{Value: 20, Unit: "USD"},
+			To:   &EntityAmount{Value: 20, Unit: "USD"},
+		}, val)
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		val, err := mustEntity(t, ducklingDuration).Duration()
+		require.NoError(t, err)
+		require.Equal(t, EntityDuration{Value: 2, Unit: GrainHour, Duration: 2 * time.Hour}, val)
+
+		// without normalized value
+		val, err = mustEntity(t, `{"entity": "duration", "value": 3, "additional_info": {"value": 3, "unit": "day"}}`).Duration()
+		require.NoError(t, err)
+		require.Equal(t, 72*time.Hour, val.Duration)
+	})
+}
+
+// TestTrackerEntities
+func TestTrackerEntities(t *testing.T) {
+	tracker := &Tracker{LatestMessage: &ParseResult{
+		Entities: []Entity{
+			*mustEntity(t, ducklingTomorrow),
+			*mustEntity(t, ducklingMoney),
+			*mustEntity(t, ducklingDuration),
+			*mustEntity(t, ducklingMoneyRange),
+		},
+	}}
+
+	tm, err := tracker.EntityTime(DimensionTime, "", "")
+	require.NoError(t, err)
+	require.Equal(t, GrainDay, tm.Grain)
+
+	interval, err := tracker.EntityInterval(DimensionTime, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, interval.To)
+
+	amount, err := tracker.EntityAmount(DimensionAmountOfMoney, "", "")
+	require.NoError(t, err)
+	require.Equal(t, "USD", amount.Unit)
+
+	rng, err := tracker.EntityAmountRange(DimensionAmountOfMoney, "", "")
+	require.NoError(t, err)
+	require.Equal(t, 20.0, rng.To.Value)
+
+	duration, err := tracker.EntityDuration(DimensionDuration, "", "")
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, duration.Duration)
+
+	_, err = tracker.EntityTime(DimensionTime, "departure", "")
+	require.True(t, errors.Is(err, ErrEntityNotFound))
+	require.EqualError(t, err, "entity [time] not found")
+
+	_, err = tracker.EntityAmount(DimensionTime, "", "")
+	require.Error(t, err)
+}
@@ -9,9 +9,12 @@ package rasa
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/fatih/structs"
 	perrors "github.com/pkg/errors"
 	"go.scarlet.dev/errors"
 )
@@ -30,6 +33,7 @@ const (
 	EventTypeBotUttered              = EventType("bot")
 	EventTypeConversationPaused      = EventType("pause")
 	EventTypeConversationResumed     = EventType("resume")
+	EventTypeEntitiesAdded           = EventType("entities")
 	EventTypeFollowupAction          = EventType("followup")
 	EventTypeLoopInterrupted         = EventType("loop_interrupted")
 	EventTypeReminderCancelled       = EventType("cancel_reminder")
@@ -66,15 +70,16 @@ func (l *Events) UnmarshalJSON(data []byte) (err error) {
 		return perrors.WithMessage(err, "unable to unmarshal EventList")
 	})
 
-	// initialize as empty eventlist
-	*l = Events{}
-
 	// get the Raw event messages
 	var events []json.RawMessage
 	if err = json.Unmarshal(data, &events); err != nil {
+		*l = Events{}
 		return
 	}
 
+	// initialize as empty eventlist
+	*l = make(Events, 0, len(events))
+
 	for i := range events {
 		var evt Event
 		if evt, err = unmarshalEvent(events[i]); err != nil {
@@ -86,7 +91,73 @@ func (l *Events) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+// eventTypes holds the constructors of the registered event types.
+var eventTypes = struct {
+	sync.RWMutex
+	m map[EventType]func() Event
+}{
+	m: map[EventType]func() Event{
+		EventTypeActionExecuted:          func() Event { return new(ActionExecuted) },
+		EventTypeActionExecutionRejected: func() Event { return new(ActionExecutionRejected) },
+		EventTypeActionReverted:          func() Event { return new(ActionReverted) },
+		EventTypeActiveLoop:              func() Event { return new(ActiveLoop) },
+		EventTypeAgentUttered:            func() Event { return new(AgentUttered) },
+		EventTypeAllSlotsReset:           func() Event { return new(AllSlotsReset) },
+		EventTypeBotUttered:              func() Event { return new(BotUttered) },
+		EventTypeConversationPaused:      func() Event { return new(ConversationPaused) },
+		EventTypeConversationResumed:     func() Event { return new(ConversationResumed) },
+		EventTypeEntitiesAdded:           func() Event { return new(EntitiesAdded) },
+		EventTypeFollowupAction:          func() Event { return new(FollowupAction) },
+		EventTypeLoopInterrupted:         func() Event { return new(LoopInterrupted) },
+		EventTypeReminderCancelled:       func() Event { return new(ReminderCancelled) },
+		EventTypeReminderScheduled:       func() Event { return new(ReminderScheduled) },
+		EventTypeRestarted:               func() Event { return new(Restarted) },
+		EventTypeSessionStarted:          func() Event { return new(SessionStarted) },
+		EventTypeSlotSet:                 func() Event { return new(SlotSet) },
+		EventTypeStoryExported:           func() Event { return new(StoryExported) },
+		EventTypeUserFeaturization:       func() Event { return new(UserFeaturization) },
+		EventTypeUserUtteranceReverted:   func() Event { return new(UserUtteranceReverted) },
+		EventTypeUserUttered:             func() Event { return new(UserUttered) },
+	},
+}
+
+// RegisterEventType registers a constructor for events of the provided type.
+//
+// Events of a registered type are unmarshalled by passing their JSON to the
+// value returned by fn, which must therefore be a pointer implementing
+// json.Unmarshaler or a pointer to a struct. The event should implement
+// json.Marshaler to include the "event" property when marshalled, for
+// example by calling MarshalEvent.
+//
+// Every event type can only be registered once, so the function will panic
+// if the type is already registered, including the types provided by the
+// SDK. RegisterEventType should be called during initialization.
+func RegisterEventType(typ EventType, fn func() Event) {
+	eventTypes.Lock()
+	defer eventTypes.Unlock()
+
+	if _, exists := eventTypes.m[typ]; exists {
+		panic(fmt.Sprintf("event type [%s] already registered", typ))
+	}
+	eventTypes.m[typ] = fn
+}
+
+// newEvent returns a new Event for the provided type, or nil if the type is
+// not registered.
+func newEvent(typ EventType) Event {
+	eventTypes.RLock()
+	fn, exists := eventTypes.m[typ]
+	eventTypes.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	return fn()
+}
+
 // unmarshalEvent will unmarshal the provided serialized JSON as an Event.
+//
+// Events of unknown types are returned as a RawEvent.
 func unmarshalEvent(data []byte) (evt Event, err error) {
 	// marker is used to determine the type of the serialized JSON object.
 	//
@@ -101,51 +172,13 @@ func unmarshalEvent(data []byte) (evt Event, err error) {
 		return
 	}
 
-	switch marker.Event {
-	default:
-		// error case - unknown of unsupported event type
-		err = fmt.Errorf("invalid event type [%s]", marker.Event)
+	if evt = newEvent(marker.Event); evt == nil {
+		// unknown or unsupported event type - keep the original JSON
+		evt = &RawEvent{
+			EventType: marker.Event,
+			Data:      append(json.RawMessage(nil), data...),
+		}
 		return
-	case EventTypeActionExecuted:
-		evt = new(ActionExecuted)
-	case EventTypeActionExecutionRejected:
-		evt = new(ActionExecutionRejected)
-	case EventTypeActionReverted:
-		evt = new(ActionReverted)
-	case EventTypeActiveLoop:
-		evt = new(ActiveLoop)
-	case EventTypeAgentUttered:
-		evt = new(AgentUttered)
-	case EventTypeAllSlotsReset:
-		evt = new(AllSlotsReset)
-	case EventTypeBotUttered:
-		evt = new(BotUttered)
-	case EventTypeConversationPaused:
-		evt = new(ConversationPaused)
-	case EventTypeConversationResumed:
-		evt = new(ConversationResumed)
-	case EventTypeFollowupAction:
-		evt = new(FollowupAction)
-	case EventTypeLoopInterrupted:
-		evt = new(LoopInterrupted)
-	case EventTypeReminderCancelled:
-		evt = new(ReminderCancelled)
-	case EventTypeReminderScheduled:
-		evt = new(ReminderScheduled)
-	case EventTypeRestarted:
-		evt = new(Restarted)
-	case EventTypeSessionStarted:
-		evt = new(SessionStarted)
-	case EventTypeSlotSet:
-		evt = new(SlotSet)
-	case EventTypeStoryExported:
-		evt = new(StoryExported)
-	case EventTypeUserFeaturization:
-		evt = new(UserFeaturization)
-	case EventTypeUserUtteranceReverted:
-		evt = new(UserUtteranceReverted)
-	case EventTypeUserUttered:
-		evt = new(UserUttered)
 	}
 
 	// unmarshal the JSON event
@@ -153,66 +186,393 @@ func unmarshalEvent(data []byte) (evt Event, err error) {
 	return
 }
 
-// structToMap implements a single-layer conversion from a struct to a
-// string-indexed map.
-func structToMap(s interface{}) map[string]interface{} {
-	// TODO(ed): replace this with a self-maintained implementation?
-	st := structs.New(s)
-	st.TagName = "json"
-	return st.Map()
+// ensure interface
+var _ json.Marshaler = (Events)(nil)
+
+// MarshalJSON implements json.Marshaler.
+//
+// Events stored by value are marshalled through a pointer, as the events of
+// the SDK implement json.Marshaler on their pointer types. Lazy events are
+// marshalled as is, and the original JSON of raw events is written back
+// unchanged.
+func (l Events) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("null"), nil
+	}
+
+	buf := append(make([]byte, 0, 64*len(l)), '[')
+	for i := range l {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+
+		evt := l[i]
+		if raw, ok := evt.(*RawEvent); ok && len(raw.Data) > 0 {
+			// the original JSON is written back as is
+			buf = append(buf, raw.Data...)
+			continue
+		}
+		if evt != nil && reflect.ValueOf(evt).Kind() != reflect.Ptr {
+			evt = eventPointer(evt)
+		}
+		data, err := json.Marshal(evt)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+	return append(buf, ']'), nil
+}
+
+// EventPointer returns evt as a pointer to its concrete type, which allows
+// type switches to only handle the pointer types, such as *SlotSet. Lazy
+// events are decoded.
+func EventPointer(evt Event) Event {
+	return eventPointer(evt)
+}
+
+// eventPointer returns evt as a pointer to its concrete type.
+//
+// Events may be stored in an Events list either by value or by reference. The
+// SDK only switches on the pointer types, so value events are copied into a
+// newly allocated value first. Lazy events are decoded; if decoding fails,
+// the LazyEvent itself is returned.
+func eventPointer(evt Event) Event {
+	if lazy, ok := evt.(*LazyEvent); ok {
+		decoded, err := lazy.Decode()
+		if err != nil {
+			return lazy
+		}
+		evt = decoded
+	}
+
+	rv := reflect.ValueOf(evt)
+	if rv.Kind() == reflect.Ptr {
+		return evt
+	}
+
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	if e, ok := ptr.Interface().(Event); ok {
+		return e
+	}
+	return evt
 }
 
-// marshalEvent will marshal the provided Event value into its JSON
-// representation.
-func marshalEvent(e Event) ([]byte, error) {
-	// turn the event into a map
-	result := structToMap(e)
+// MarshalEvent marshals the provided Event into its JSON representation,
+// including the "event" property holding the type of the event.
+//
+// MarshalEvent can be used to implement json.Marshaler for custom event types
+// registered with RegisterEventType. The exported fields of the event are
+// marshalled according to their json tags, with the fields of embedded
+// structs flattened like encoding/json does, ignoring any json.Marshaler
+// implemented by the event itself.
+func MarshalEvent(e Event) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(e))
+	if rv.Kind() != reflect.Struct {
+		return newEventObject(e.Type(), Time{}, 32).Bytes()
+	}
+
+	// copy the fields into a struct without methods, to avoid calling the
+	// MarshalJSON method of the event recursively.
+	plain := plainStructType(rv.Type())
+	value := reflect.New(plain.typ).Elem()
+	for i := range plain.fields {
+		src, ok := fieldByIndex(rv, plain.fields[i].index)
+		if !ok {
+			// the field is promoted through a nil pointer
+			continue
+		}
+
+		dst := value.Field(i)
+		if plain.fields[i].wrapped {
+			// optional fields are left nil to be omitted
+			if plain.fields[i].omitEmpty && isEmptyValue(src) {
+				continue
+			}
+			ptr := reflect.New(src.Type())
+			ptr.Elem().Set(src)
+			dst.Set(ptr)
+		} else {
+			dst.Set(src)
+		}
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "unable to marshal event [%s]", e.Type())
+	}
 
 	// add the event type property
-	result["event"] = e.Type()
-	return json.Marshal(result)
+	o := newEventObject(e.Type(), Time{}, len(data)+32)
+	if len(data) > 2 {
+		o.buf = append(o.buf, ',')
+		o.buf = append(o.buf, data[1:len(data)-1]...)
+	}
+	return o.Bytes()
+}
+
+// plainStructTypes caches the types created by plainStructType.
+var plainStructTypes sync.Map
+
+// timeType holds the reflected type of Time.
+var timeType = reflect.TypeOf(Time{})
+
+// plainStruct holds a struct type created by plainStructType, and the
+// fields of the original type from which its fields are copied.
+type plainStruct struct {
+	typ    reflect.Type
+	fields []plainField
 }
 
-// ActionExecuted TODO
+// plainField describes a field of a plainStruct.
+type plainField struct {
+	// index holds the index sequence of the field in the original type.
+	index []int
+
+	// name holds the name of the field in JSON, and tagged indicates that
+	// the name is provided by the json tag.
+	name   string
+	tagged bool
+
+	// omitEmpty indicates that the field is tagged with omitempty.
+	omitEmpty bool
+
+	// wrapped indicates that the field of the plain struct is a pointer to
+	// the value of the field, which is nil if the field is omitted.
+	wrapped bool
+
+	// field holds the field of the plain struct.
+	field reflect.StructField
+}
+
+// plainStructType returns a struct type holding the exported fields of typ,
+// without any of its methods. The fields of embedded structs are flattened
+// following the rules of encoding/json. Time fields tagged with omitempty
+// and fields promoted through embedded pointers are turned into pointers, so
+// that they can be omitted the same way as they are for the events of the
+// SDK and by encoding/json.
+func plainStructType(typ reflect.Type) *plainStruct {
+	if cached, ok := plainStructTypes.Load(typ); ok {
+		return cached.(*plainStruct)
+	}
+
+	// keep the fields of the lowest depth for every name, preferring tagged
+	// fields, and drop the name if this is ambiguous
+	var fields []plainField
+	collectPlainFields(typ, nil, false, &fields)
+	result := &plainStruct{}
+	for i := range fields {
+		if dominantField(fields, fields[i].name) == i {
+			result.fields = append(result.fields, fields[i])
+		}
+	}
+
+	structFields := make([]reflect.StructField, len(result.fields))
+	for i := range result.fields {
+		structFields[i] = result.fields[i].field
+		structFields[i].Name = "F" + strconv.Itoa(i)
+	}
+	result.typ = reflect.StructOf(structFields)
+
+	plainStructTypes.Store(typ, result)
+	return result
+}
+
+// collectPlainFields appends the exported fields of typ to fields, with the
+// fields of embedded structs flattened. The fields are appended in the order
+// of their index sequence, which is prefixed with index.
+func collectPlainFields(typ reflect.Type, index []int, optional bool, fields *[]plainField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.IndexByte(tag, ','); comma >= 0 {
+			name, opts = tag[:comma], tag[comma:]
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if field.Anonymous {
+			t := field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if name == "" && t.Kind() == reflect.Struct {
+				collectPlainFields(t, fieldIndex, optional || field.Type.Kind() == reflect.Ptr, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		f := plainField{
+			index:     fieldIndex,
+			name:      name,
+			tagged:    name != "",
+			omitEmpty: strings.Contains(opts, ",omitempty"),
+		}
+		if !f.tagged {
+			f.name = field.Name
+		}
+
+		fieldType := field.Type
+		if optional || (fieldType == timeType && f.omitEmpty) {
+			// nil pointers are omitted with omitempty
+			f.wrapped = true
+			fieldType = reflect.PtrTo(fieldType)
+			if !f.omitEmpty {
+				opts += ",omitempty"
+			}
+		}
+		f.field = reflect.StructField{
+			Type: fieldType,
+			Tag:  reflect.StructTag(`json:"` + f.name + opts + `"`),
+		}
+		*fields = append(*fields, f)
+	}
+}
+
+// dominantField returns the index of the field with the name which is
+// marshalled by encoding/json, or -1 if there is none.
+func dominantField(fields []plainField, name string) int {
+	dominant, ambiguous := -1, false
+	for i := range fields {
+		if fields[i].name != name {
+			continue
+		}
+		if dominant < 0 || len(fields[i].index) < len(fields[dominant].index) {
+			dominant, ambiguous = i, false
+			continue
+		}
+		if len(fields[i].index) > len(fields[dominant].index) {
+			continue
+		}
+		switch {
+		case fields[i].tagged == fields[dominant].tagged:
+			ambiguous = true
+		case fields[i].tagged:
+			dominant, ambiguous = i, false
+		}
+	}
+	if ambiguous {
+		return -1
+	}
+	return dominant
+}
+
+// fieldByIndex returns the nested field of v with the index sequence, and
+// false if the field is promoted through a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue returns whether v is omitted by encoding/json if tagged with
+// omitempty. Time values are empty if they are the zero time.
+func isEmptyValue(v reflect.Value) bool {
+	if v.Type() == timeType {
+		return v.Interface().(Time).isZero()
+	}
+
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// newEventObject starts the JSON object of an event with the "event" and
+// "timestamp" properties. The timestamp is omitted if it is the zero time.
+func newEventObject(typ EventType, ts Time, capacity int) *jsonObject {
+	o := newJSONObject(capacity)
+	o.String("event", string(typ))
+	o.Time("timestamp", ts)
+	return o
+}
+
+// ActionExecuted is logged after an action was executed by the bot.
+//
+// For end-to-end stories, ActionText holds the text of the bot response
+// instead of an ActionName.
 type ActionExecuted struct {
-	Timestamp  Time    `json:"timestamp,omitempty"`
-	ActionName string  `json:"name"`
-	Policy     string  `json:"policy,omitempty"`
-	Confidence float32 `json:"confidence,omitempty"`
+	Timestamp    Time    `json:"timestamp,omitempty"`
+	ActionName   string  `json:"name,omitempty"`
+	Policy       string  `json:"policy,omitempty"`
+	Confidence   float64 `json:"confidence,omitempty"`
+	ActionText   string  `json:"action_text,omitempty"`
+	HideRuleTurn bool    `json:"hide_rule_turn,omitempty"`
+	Metadata     JSONMap `json:"metadata,omitempty"`
 }
 
-// ActionExecutionRejected TODO
+// ActionExecutionRejected is logged when an action rejected its execution,
+// such as a form which was unable to extract a slot.
 type ActionExecutionRejected struct {
 	Timestamp  Time    `json:"timestamp,omitempty"`
 	ActionName string  `json:"name"`
 	Policy     string  `json:"policy,omitempty"`
-	Confidence float32 `json:"confidence,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Metadata   JSONMap `json:"metadata,omitempty"`
 }
 
-// ActionReverted TODO
+// ActionReverted undoes the latest action, and all events that followed it.
 type ActionReverted struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// ActiveLoop TODO
+// ActiveLoop activates the loop with the given Name. An empty Name
+// deactivates the active loop.
 type ActiveLoop struct {
-	Timestamp Time   `json:"timestamp,omitempty"`
-	Name      string `json:"name,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// AgentUttered TODO
+// AgentUttered is logged for messages sent by an external agent.
 type AgentUttered struct {
-	Timestamp Time    `json:"timestamp,omitempty"`
-	Text      string  `json:"test,omitempty"`
-	Data      JSONMap `json:"data,omitempty"`
+	Timestamp Time        `json:"timestamp,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Metadata  JSONMap     `json:"metadata,omitempty"`
 }
 
-// AllSlotsReset TODO
+// AllSlotsReset resets all slots to their initial value.
 type AllSlotsReset struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// BotUttered TODO
+// BotUttered is logged for messages sent by the bot to the user.
+//
+// Data holds the rich response content, such as "buttons", "elements",
+// "quick_replies", "image", "attachment", and "custom". Metadata holds the
+// metadata of the response, including the name of the response under the
+// "utter_action" key.
 type BotUttered struct {
 	Timestamp Time    `json:"timestamp,omitempty"`
 	Text      string  `json:"text,omitempty"`
@@ -220,37 +580,65 @@ type BotUttered struct {
 	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// ConversationPaused TODO
+// UtterAction returns the name of the response which generated the message,
+// or an empty string if the message was not generated from a response.
+func (e *BotUttered) UtterAction() string {
+	name, _ := e.Metadata["utter_action"].(string)
+	return name
+}
+
+// ConversationPaused pauses the conversation. The bot will not respond to
+// user messages until the conversation is resumed.
 type ConversationPaused struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// ConversationResumed TODO
+// ConversationResumed resumes a paused conversation.
 type ConversationResumed struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
+}
+
+// EntitiesAdded adds entities to the latest user message.
+type EntitiesAdded struct {
+	Timestamp Time     `json:"timestamp,omitempty"`
+	Entities  []Entity `json:"entities"`
+	Metadata  JSONMap  `json:"metadata,omitempty"`
 }
 
-// FollowupAction TODO
+// FollowupAction forces the next action to be the action with the given
+// name.
 type FollowupAction struct {
-	Timestamp  Time   `json:"timestamp,omitempty"`
-	ActionName string `json:"name"`
+	Timestamp  Time    `json:"timestamp,omitempty"`
+	ActionName string  `json:"name"`
+	Metadata   JSONMap `json:"metadata,omitempty"`
 }
 
-// LoopInterrupted TODO
+// LoopInterrupted marks the active loop as interrupted, which disables its
+// validation.
 type LoopInterrupted struct {
-	Timestamp     Time `json:"timestamp,omitempty"`
-	IsInterrupted bool `json:"is_interrupted,omitempty"`
+	Timestamp     Time    `json:"timestamp,omitempty"`
+	IsInterrupted bool    `json:"is_interrupted"`
+	Metadata      JSONMap `json:"metadata,omitempty"`
 }
 
-// ReminderCancelled TODO
+// ReminderCancelled cancels the reminders matching the given name, intent,
+// and entities.
 type ReminderCancelled struct {
 	Timestamp  Time      `json:"timestamp,omitempty"`
 	Name       string    `json:"name,omitempty"`
 	IntentName string    `json:"intent,omitempty"`
 	Entities   []JSONMap `json:"entities,omitempty"`
+	Metadata   JSONMap   `json:"metadata,omitempty"`
 }
 
-// ReminderScheduled TODO
+// ReminderScheduled schedules the intent to be triggered at DateTime.
+//
+// DateTime is serialized in the ISO 8601 format used by Rasa, including the
+// offset of its location. Rasa interprets date times without an offset in
+// the local timezone of the Rasa server; when unmarshalling, such values are
+// interpreted as UTC.
 type ReminderScheduled struct {
 	Timestamp         Time      `json:"timestamp,omitempty"`
 	Name              string    `json:"name,omitempty"`
@@ -258,48 +646,66 @@ type ReminderScheduled struct {
 	Entities          []JSONMap `json:"entities,omitempty"`
 	DateTime          time.Time `json:"date_time"`
 	KillOnUserMessage bool      `json:"kill_on_user_msg"`
+	Metadata          JSONMap   `json:"metadata,omitempty"`
 }
 
-// Restarted TODO
+// Restarted restarts the conversation, resetting the tracker.
 type Restarted struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// SessionStarted TODO
+// SessionStarted marks the start of a new conversation session.
 type SessionStarted struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// SlotSet TODO
+// SlotSet sets the slot with the given Key to Value. A nil Value resets the
+// slot.
 type SlotSet struct {
 	Timestamp Time        `json:"timestamp,omitempty"`
 	Key       string      `json:"name"`
-	Value     interface{} `json:"value,omitempty"`
+	Value     interface{} `json:"value"`
+	Metadata  JSONMap     `json:"metadata,omitempty"`
 }
 
-// StoryExported TODO
+// StoryExported requests Rasa to export the conversation as a story.
 type StoryExported struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Path      string  `json:"path,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// UserFeaturization TODO
+// UserFeaturization defines whether the text or the intent of the previous
+// user message is used for featurization.
+//
+// It is known as DefinePrevUserUtteredFeaturization in Rasa 3.
 type UserFeaturization struct {
-	Timestamp               Time `json:"timestamp,omitempty"`
-	UseTextForFeaturization bool `json:"use_text_for_featurization"`
+	Timestamp               Time    `json:"timestamp,omitempty"`
+	UseTextForFeaturization bool    `json:"use_text_for_featurization"`
+	Metadata                JSONMap `json:"metadata,omitempty"`
 }
 
-// UserUtteranceReverted TODO
+// DefinePrevUserUtteredFeaturization is the name used by Rasa 3 for the
+// UserFeaturization event.
+type DefinePrevUserUtteredFeaturization = UserFeaturization
+
+// UserUtteranceReverted undoes the latest user message, and all events that
+// followed it.
 type UserUtteranceReverted struct {
-	Timestamp Time `json:"timestamp,omitempty"`
+	Timestamp Time    `json:"timestamp,omitempty"`
+	Metadata  JSONMap `json:"metadata,omitempty"`
 }
 
-// UserUttered TODO
+// UserUttered is logged for messages sent by the user.
 type UserUttered struct {
 	Timestamp    Time         `json:"timestamp,omitempty"`
 	Text         string       `json:"text,omitempty"`
 	ParseData    *ParseResult `json:"parse_data,omitempty"`
 	InputChannel string       `json:"input_channel,omitempty"`
-	Metadata     JSONMap      `json:"metadata,omitempty"` // experimental
+	MessageID    string       `json:"message_id,omitempty"`
+	Metadata     JSONMap      `json:"metadata,omitempty"`
 }
 
 // Type implements Event.
@@ -329,6 +735,9 @@ func (ConversationPaused) Type() EventType { return EventTypeConversationPaused
 // Type implements Event.
 func (ConversationResumed) Type() EventType { return EventTypeConversationResumed }
 
+// Type implements Event.
+func (EntitiesAdded) Type() EventType { return EventTypeEntitiesAdded }
+
 // Type implements Event.
 func (FollowupAction) Type() EventType { return EventTypeFollowupAction }
 
@@ -363,7 +772,7 @@ func (UserUtteranceReverted) Type() EventType { return EventTypeUserUtteranceRev
 func (UserUttered) Type() EventType { return EventTypeUserUttered }
 
 // Time implements Event.
-func (e *ActionExecuted) Time() time.Time {
+func (e ActionExecuted) Time() time.Time {
 	return e.Timestamp.AsTime()
 }
 
@@ -407,6 +816,11 @@ func (e ConversationResumed) Time() time.Time {
 	return e.Timestamp.AsTime()
 }
 
+// Time implements Event.
+func (e EntitiesAdded) Time() time.Time {
+	return e.Timestamp.AsTime()
+}
+
 // Time implements Event.
 func (e FollowupAction) Time() time.Time {
 	return e.Timestamp.AsTime()
@@ -463,64 +877,206 @@ func (e UserUttered) Time() time.Time {
 }
 
 // MarshalJSON implements json.Marshaler.
-func (e *ActionExecuted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ActionExecuted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 128)
+	o.StringOmitEmpty("name", e.ActionName)
+	o.StringOmitEmpty("policy", e.Policy)
+	o.FloatOmitEmpty("confidence", e.Confidence)
+	o.StringOmitEmpty("action_text", e.ActionText)
+	o.BoolOmitEmpty("hide_rule_turn", e.HideRuleTurn)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *ActionExecutionRejected) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 128)
+	o.String("name", e.ActionName)
+	o.StringOmitEmpty("policy", e.Policy)
+	o.FloatOmitEmpty("confidence", e.Confidence)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ActionExecutionRejected) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ActionReverted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ActionReverted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ActiveLoop) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.StringOmitEmpty("name", e.Name)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ActiveLoop) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *AgentUttered) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 128)
+	o.StringOmitEmpty("text", e.Text)
+	o.ValueOmitEmpty("data", e.Data)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *AgentUttered) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *AllSlotsReset) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *AllSlotsReset) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *BotUttered) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 256)
+	o.StringOmitEmpty("text", e.Text)
+	o.Map("data", e.Data)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *BotUttered) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ConversationPaused) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ConversationPaused) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ConversationResumed) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ConversationResumed) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *EntitiesAdded) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 256)
+	o.Value("entities", e.Entities)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *FollowupAction) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *FollowupAction) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.String("name", e.ActionName)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *LoopInterrupted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *LoopInterrupted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Bool("is_interrupted", e.IsInterrupted)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ReminderCancelled) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ReminderCancelled) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 128)
+	o.StringOmitEmpty("name", e.Name)
+	o.StringOmitEmpty("intent", e.IntentName)
+	if len(e.Entities) > 0 {
+		o.Value("entities", e.Entities)
+	}
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *ReminderScheduled) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *ReminderScheduled) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 192)
+	o.StringOmitEmpty("name", e.Name)
+	o.StringOmitEmpty("intent", e.IntentName)
+	if len(e.Entities) > 0 {
+		o.Value("entities", e.Entities)
+	}
+	o.String("date_time", formatISOTime(e.DateTime))
+	o.Bool("kill_on_user_msg", e.KillOnUserMessage)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *Restarted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *Restarted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *SessionStarted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *SessionStarted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *SlotSet) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *SlotSet) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 128)
+	o.String("name", e.Key)
+	o.Value("value", e.Value)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *StoryExported) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *StoryExported) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.StringOmitEmpty("path", e.Path)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *UserFeaturization) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *UserFeaturization) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 96)
+	o.Bool("use_text_for_featurization", e.UseTextForFeaturization)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *UserUtteranceReverted) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *UserUtteranceReverted) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 64)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
 
 // MarshalJSON implements json.Marshaler.
-func (e *UserUttered) MarshalJSON() ([]byte, error) { return marshalEvent(e) }
+func (e *UserUttered) MarshalJSON() ([]byte, error) {
+	o := newEventObject(e.Type(), e.Timestamp, 512)
+	o.StringOmitEmpty("text", e.Text)
+	if e.ParseData != nil {
+		o.Value("parse_data", e.ParseData)
+	}
+	o.StringOmitEmpty("input_channel", e.InputChannel)
+	o.StringOmitEmpty("message_id", e.MessageID)
+	o.Map("metadata", e.Metadata)
+	return o.Bytes()
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *ReminderScheduled) UnmarshalJSON(data []byte) (err error) {
+	type alias ReminderScheduled
+	aux := struct {
+		*alias
+		DateTime string `json:"date_time"`
+	}{
+		alias: (*alias)(e),
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	e.DateTime, err = parseISOTime(aux.DateTime)
+	return
+}
 
 // ensure interfaces
 var _ Event = (*ActionExecuted)(nil)
@@ -532,6 +1088,7 @@ var _ Event = (*AllSlotsReset)(nil)
 var _ Event = (*BotUttered)(nil)
 var _ Event = (*ConversationPaused)(nil)
 var _ Event = (*ConversationResumed)(nil)
+var _ Event = (*EntitiesAdded)(nil)
 var _ Event = (*FollowupAction)(nil)
 var _ Event = (*LoopInterrupted)(nil)
 var _ Event = (*ReminderCancelled)(nil)
@@ -554,6 +1111,7 @@ var _ json.Marshaler = (*AllSlotsReset)(nil)
 var _ json.Marshaler = (*BotUttered)(nil)
 var _ json.Marshaler = (*ConversationPaused)(nil)
 var _ json.Marshaler = (*ConversationResumed)(nil)
+var _ json.Marshaler = (*EntitiesAdded)(nil)
 var _ json.Marshaler = (*FollowupAction)(nil)
 var _ json.Marshaler = (*LoopInterrupted)(nil)
 var _ json.Marshaler = (*ReminderCancelled)(nil)
@@ -565,3 +1123,6 @@ var _ json.Marshaler = (*StoryExported)(nil)
 var _ json.Marshaler = (*UserFeaturization)(nil)
 var _ json.Marshaler = (*UserUtteranceReverted)(nil)
 var _ json.Marshaler = (*UserUttered)(nil)
+
+// ensure interfaces
+var _ json.Unmarshaler = (*ReminderScheduled)(nil)
@@ -0,0 +1,239 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	perrors "github.com/pkg/errors"
+)
+
+// LazyEvent holds the JSON of an event, which is only decoded when the event
+// is accessed.
+//
+// Lazy events are created by UnmarshalLazyEvents and UnmarshalLazyTracker.
+// The decoded event is available through Decode, Events.At, and the
+// EventIterator. Unless it was decoded, a LazyEvent is marshalled as its
+// original JSON.
+type LazyEvent struct {
+	data json.RawMessage
+
+	// header holds the type and timestamp of the event.
+	headerOnce sync.Once
+	header     struct {
+		Event     EventType `json:"event"`
+		Timestamp Time      `json:"timestamp"`
+	}
+
+	// evt holds the decoded event.
+	mu      sync.Mutex
+	decoded bool
+	evt     Event
+	err     error
+}
+
+// ensure interfaces
+var _ Event = (*LazyEvent)(nil)
+var _ json.Marshaler = (*LazyEvent)(nil)
+
+// NewLazyEvent creates a LazyEvent for the provided JSON. The data is not
+// copied.
+func NewLazyEvent(data json.RawMessage) *LazyEvent {
+	return &LazyEvent{data: data}
+}
+
+// Type implements Event.
+//
+// Only the "event" and "timestamp" properties are decoded to determine the
+// type. An empty type is returned for invalid JSON.
+func (e *LazyEvent) Type() EventType {
+	e.decodeHeader()
+	return e.header.Event
+}
+
+// Time implements Event.
+func (e *LazyEvent) Time() time.Time {
+	e.decodeHeader()
+	return e.header.Timestamp.AsTime()
+}
+
+// Decode decodes the event, and returns the result. The event is decoded only
+// once; subsequent calls return the same Event.
+//
+// Events of unknown types are returned as a RawEvent.
+func (e *LazyEvent) Decode() (Event, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.decoded {
+		e.decoded = true
+		e.evt, e.err = unmarshalEvent(e.data)
+		if e.err != nil {
+			e.err = perrors.WithMessage(e.err, "unable to decode lazy event")
+		}
+	}
+	return e.evt, e.err
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// A decoded event is marshalled again, to include changes made to the
+// decoded value. Otherwise, the original JSON is returned.
+func (e *LazyEvent) MarshalJSON() ([]byte, error) {
+	e.mu.Lock()
+	evt := e.evt
+	e.mu.Unlock()
+
+	if evt != nil {
+		return json.Marshal(evt)
+	}
+	return e.data, nil
+}
+
+// decodeHeader decodes the type and timestamp of the event.
+func (e *LazyEvent) decodeHeader() {
+	e.headerOnce.Do(func() {
+		_ = json.Unmarshal(e.data, &e.header)
+	})
+}
+
+// UnmarshalLazyEvents unmarshals a JSON array of events without decoding the
+// individual events. Every element of the result is a *LazyEvent.
+func UnmarshalLazyEvents(data []byte) (Events, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, perrors.WithMessage(err, "unable to unmarshal EventList")
+	}
+
+	events := make(Events, len(raw))
+	for i := range raw {
+		events[i] = NewLazyEvent(raw[i])
+	}
+	return events, nil
+}
+
+// UnmarshalLazyTracker unmarshals the tracker without decoding its events.
+// The elements of t.Events are *LazyEvent values, which are decoded when they
+// are accessed.
+//
+// Lazy decoding reduces the cost of handling long conversations when only a
+// few events are inspected.
+func UnmarshalLazyTracker(data []byte, t *Tracker) error {
+	type alias Tracker
+	aux := struct {
+		*alias
+		Events json.RawMessage `json:"events"`
+	}{
+		alias: (*alias)(t),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	t.Events = nil
+	if len(aux.Events) == 0 || string(aux.Events) == "null" {
+		return nil
+	}
+
+	events, err := UnmarshalLazyEvents(aux.Events)
+	if err != nil {
+		return err
+	}
+	t.Events = events
+	return nil
+}
+
+// At returns the event at index i, decoding it if it is a LazyEvent.
+func (l Events) At(i int) (Event, error) {
+	if lazy, ok := l[i].(*LazyEvent); ok {
+		return lazy.Decode()
+	}
+	return l[i], nil
+}
+
+// Decode returns a copy of the list, in which all lazy events are decoded.
+func (l Events) Decode() (Events, error) {
+	events := make(Events, len(l))
+	for i := range l {
+		evt, err := l.At(i)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "event %d", i)
+		}
+		events[i] = evt
+	}
+	return events, nil
+}
+
+// Iter returns an EventIterator which visits the events from the first to the
+// last.
+func (l Events) Iter() *EventIterator {
+	return &EventIterator{events: l, index: -1, step: 1}
+}
+
+// ReverseIter returns an EventIterator which visits the events from the last
+// to the first.
+func (l Events) ReverseIter() *EventIterator {
+	return &EventIterator{events: l, index: len(l), step: -1}
+}
+
+// EventIterator iterates over a list of events, decoding lazy events as they
+// are visited.
+//
+//	it := tracker.Events.ReverseIter()
+//	for it.Next() {
+//		if e, ok := it.Event().(*rasa.SlotSet); ok {
+//			// ...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+type EventIterator struct {
+	events Events
+	index  int
+	step   int
+	evt    Event
+	err    error
+}
+
+// Next advances the iterator to the next event, and returns whether there is
+// one. Iteration stops at the first event that cannot be decoded.
+func (it *EventIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index += it.step
+	if it.index < 0 || it.index >= len(it.events) {
+		it.evt = nil
+		return false
+	}
+
+	if it.evt, it.err = it.events.At(it.index); it.err != nil {
+		it.err = perrors.WithMessagef(it.err, "event %d", it.index)
+		it.evt = nil
+		return false
+	}
+	return true
+}
+
+// Event returns the current event.
+func (it *EventIterator) Event() Event {
+	return it.evt
+}
+
+// Index returns the index of the current event.
+func (it *EventIterator) Index() int {
+	return it.index
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *EventIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLazyEvents
+func TestLazyEvents(t *testing.T) {
+	data := []byte(`{
+		"sender_id": "test",
+		"slots": {"city": "Paris"},
+		"events": [
+			{"event": "action", "timestamp": 1617181920.5, "name": "action_listen"},
+			{"event": "user", "text": "to Paris", "parse_data": {"intent": {"name": "inform"}}},
+			{"event": "flow_started", "flow_id": "transfer"},
+			{"event": "slot", "name": "city", "value": "Paris"}
+		]
+	}`)
+
+	t.Run("tracker", func(t *testing.T) {
+		var tracker Tracker
+		require.NoError(t, UnmarshalLazyTracker(data, &tracker))
+		require.Equal(t, "test", tracker.SenderID)
+		require.Equal(t, Slots{"city": "Paris"}, tracker.Slots)
+		require.Len(t, tracker.Events, 4)
+
+		for i := range tracker.Events {
+			require.IsTypef(t, &LazyEvent{}, tracker.Events[i], "failed on %d", i)
+		}
+
+		lazy := tracker.Events[0].(*LazyEvent)
+		require.Equal(t, EventTypeActionExecuted, lazy.Type())
+		require.Equal(t, time.Unix(1617181920, 500000000), lazy.Time())
+
+		evt, err := tracker.Events.At(1)
+		require.NoError(t, err)
+		require.Equal(t, &UserUttered{Text: "to Paris", ParseData: &ParseResult{Intent: Intent{Name: "inform"}}}, evt)
+
+		evt, err = tracker.Events.At(2)
+		require.NoError(t, err)
+		require.IsType(t, &RawEvent{}, evt)
+
+		require.Equal(t, Slots{"city": "Paris"}, tracker.SlotsToValidate())
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var tracker Tracker
+		require.NoError(t, UnmarshalLazyTracker(data, &tracker))
+
+		var types []EventType
+		for it := tracker.Events.Iter(); it.Next(); {
+			types = append(types, it.Event().Type())
+		}
+		require.Equal(t, []EventType{"action", "user", "flow_started", "slot"}, types)
+
+		it := tracker.Events.ReverseIter()
+		require.True(t, it.Next())
+		require.Equal(t, 3, it.Index())
+		require.Equal(t, &SlotSet{Key: "city", Value: "Paris"}, it.Event())
+		require.NoError(t, it.Err())
+
+		events, err := UnmarshalLazyEvents([]byte(`[{"event":"slot"},{"event":"slot","name":1}]`))
+		require.NoError(t, err)
+		it = events.Iter()
+		require.True(t, it.Next())
+		require.False(t, it.Next())
+		require.Error(t, it.Err())
+		require.Equal(t, 1, it.Index())
+
+		_, err = events.Decode()
+		require.Error(t, err)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		var tracker Tracker
+		require.NoError(t, UnmarshalLazyTracker(data, &tracker))
+
+		// decoded events include changes
+		evt, err := tracker.Events.At(3)
+		require.NoError(t, err)
+		evt.(*SlotSet).Value = "Rome"
+
+		ser, err := json.Marshal(tracker.Events)
+		require.NoError(t, err)
+		require.JSONEq(t, `[
+			{"event": "action", "timestamp": 1617181920.5, "name": "action_listen"},
+			{"event": "user", "text": "to Paris", "parse_data": {"intent": {"name": "inform"}}},
+			{"event": "flow_started", "flow_id": "transfer"},
+			{"event": "slot", "name": "city", "value": "Rome"}
+		]`, string(ser))
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		var lazy, eager Tracker
+		require.NoError(t, UnmarshalLazyTracker(data, &lazy))
+		require.NoError(t, json.Unmarshal(data, &eager))
+
+		lazy.ReplayFrom(lazy.Events)
+		eager.ReplayFrom(eager.Events)
+		require.Equal(t, eager.Slots, lazy.Slots)
+		require.Equal(t, eager.LatestMessage, lazy.LatestMessage)
+		require.Equal(t, eager.LatestActionName, lazy.LatestActionName)
+	})
+}
+
+// BenchmarkTrackerUnmarshalLazy
+func BenchmarkTrackerUnmarshalLazy(b *testing.B) {
+	data, err := json.Marshal(&Tracker{SenderID: "test", Events: benchmarkEvents(10000)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tracker Tracker
+		if err := UnmarshalLazyTracker(data, &tracker); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := tracker.Events.At(len(tracker.Events) - 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
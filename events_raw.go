@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RawEvent holds an event of a type which is not registered with the SDK,
+// such as events introduced by newer versions of Rasa.
+//
+// The original JSON of the event is kept, and is written back semantically
+// unchanged when the event is marshalled. This allows an action server to
+// return trackers and events it does not understand without losing data.
+// RawEvent.MarshalJSON and Events.MarshalJSON return the original bytes, but
+// encoding/json compacts the output of marshalers and escapes HTML characters
+// when they are called by json.Marshal.
+type RawEvent struct {
+	// EventType holds the value of the "event" property of the event.
+	EventType EventType
+
+	// Data holds the original JSON of the event.
+	Data json.RawMessage
+}
+
+// ensure interfaces
+var _ Event = (*RawEvent)(nil)
+var _ json.Marshaler = (*RawEvent)(nil)
+var _ json.Unmarshaler = (*RawEvent)(nil)
+
+// Type implements Event.
+func (e *RawEvent) Type() EventType { return e.EventType }
+
+// Time implements Event.
+//
+// The timestamp is read from the original JSON. The zero time is returned if
+// the event has no valid timestamp.
+func (e *RawEvent) Time() time.Time {
+	var fields struct {
+		Timestamp Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(e.Data, &fields); err != nil {
+		return zt
+	}
+	return fields.Timestamp.AsTime()
+}
+
+// Field unmarshals the property with the provided name from the original JSON
+// into dst. The ok flag indicates whether the property was present.
+func (e *RawEvent) Field(name string, dst interface{}) (ok bool, err error) {
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(e.Data, &fields); err != nil {
+		return
+	}
+
+	raw, ok := fields[name]
+	if !ok {
+		return
+	}
+	err = json.Unmarshal(raw, dst)
+	return
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *RawEvent) MarshalJSON() ([]byte, error) {
+	if len(e.Data) == 0 {
+		return json.Marshal(struct {
+			Event EventType `json:"event"`
+		}{e.EventType})
+	}
+	return e.Data, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *RawEvent) UnmarshalJSON(data []byte) error {
+	var marker struct {
+		Event EventType `json:"event"`
+	}
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return err
+	}
+
+	e.EventType = marker.Event
+	e.Data = append(e.Data[:0], data...)
+	return nil
+}
@@ -8,7 +8,11 @@ package rasa
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -23,9 +27,60 @@ func TestEvent(t *testing.T) {
 	})
 }
 
+// TestEventMarshalJSON verifies that all event types are serialized with the
+// `event` property first, and without a zero timestamp.
 func TestEventMarshalJSON(t *testing.T) {
-	// TODO(ed): test if for all Event types, the `Event` field is properly set
-	// for serialization.
+	eventTypes.RLock()
+	types := make([]EventType, 0, len(eventTypes.m))
+	for typ := range eventTypes.m {
+		types = append(types, typ)
+	}
+	eventTypes.RUnlock()
+
+	for _, typ := range types {
+		ser, err := json.Marshal(newEvent(typ))
+		require.NoErrorf(t, err, "failed on %s", typ)
+		require.Truef(t, strings.HasPrefix(string(ser), `{"event":"`+string(typ)+`"`), "failed on %s: %s", typ, ser)
+		require.NotContainsf(t, string(ser), `"timestamp"`, "failed on %s", typ)
+	}
+
+	ser, err := json.Marshal(&UserUttered{
+		Timestamp: Time(time.Unix(1617181920, 123456000)),
+		Text:      "<hello> & bye",
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"event":"user","timestamp":1617181920.123456,"text":"\u003chello\u003e \u0026 bye"}`, string(ser))
+}
+
+// TestEventFixtures verifies that events recorded from Rasa survive a
+// decode - encode round trip. The fixtures are grouped by Rasa version.
+func TestEventFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "events", "*", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for i := range files {
+		file := files[i]
+		name := filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file))
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			require.NoError(t, err)
+
+			var marker struct {
+				Event EventType `json:"event"`
+			}
+			require.NoError(t, json.Unmarshal(data, &marker))
+
+			evt, err := unmarshalEvent(data)
+			require.NoError(t, err)
+			require.Equal(t, marker.Event, evt.Type())
+			require.IsType(t, newEvent(marker.Event), evt)
+
+			ser, err := json.Marshal(evt)
+			require.NoError(t, err)
+			require.JSONEq(t, string(data), string(ser))
+		})
+	}
 }
 
 // TestEventList
@@ -52,4 +107,211 @@ func TestEventList(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("value events", func(t *testing.T) {
+		ser, err := json.Marshal(Events{
+			SlotSet{Key: "city", Value: "Paris"},
+			&ActionExecuted{ActionName: "action_test"},
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `[
+			{"event": "slot", "name": "city", "value": "Paris"},
+			{"event": "action", "name": "action_test"}
+		]`, string(ser))
+	})
+}
+
+// testCustomEvent is registered as a custom event type for the tests.
+type testCustomEvent struct {
+	Timestamp Time   `json:"timestamp,omitempty"`
+	Value     string `json:"value"`
+}
+
+func (testCustomEvent) Type() EventType                 { return "test_custom" }
+func (e *testCustomEvent) Time() time.Time              { return e.Timestamp.AsTime() }
+func (e *testCustomEvent) MarshalJSON() ([]byte, error) { return MarshalEvent(e) }
+
+// testEventBase holds the fields shared by custom events.
+type testEventBase struct {
+	Timestamp Time   `json:"timestamp,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Value     string `json:"value"`
+}
+
+// testEmbeddedEvent is a custom event type with embedded fields.
+type testEmbeddedEvent struct {
+	testEventBase
+	*Intent
+	Value int `json:"value"` // shadows the value of the base
+}
+
+func (testEmbeddedEvent) Type() EventType                 { return "test_embedded" }
+func (e *testEmbeddedEvent) Time() time.Time              { return e.Timestamp.AsTime() }
+func (e *testEmbeddedEvent) MarshalJSON() ([]byte, error) { return MarshalEvent(e) }
+
+func init() {
+	RegisterEventType("test_custom", func() Event { return new(testCustomEvent) })
+	RegisterEventType("test_embedded", func() Event { return new(testEmbeddedEvent) })
+}
+
+// TestRawEvent
+func TestRawEvent(t *testing.T) {
+	data := []byte(`[{"event":"slot","name":"city","value":"Paris"},{"event":"flow_started","timestamp":1600000000,"flow_id":"transfer","metadata":{"step":1}}]`)
+
+	var events Events
+	require.NoError(t, json.Unmarshal(data, &events))
+	require.Len(t, events, 2)
+
+	raw, ok := events[1].(*RawEvent)
+	require.True(t, ok)
+	require.Equal(t, EventType("flow_started"), raw.Type())
+	require.Equal(t, time.Unix(1600000000, 0), raw.Time())
+
+	var flow string
+	ok, err := raw.Field("flow_id", &flow)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "transfer", flow)
+
+	ser, err := json.Marshal(raw)
+	require.NoError(t, err)
+	require.Equal(t, `{"event":"flow_started","timestamp":1600000000,"flow_id":"transfer","metadata":{"step":1}}`, string(ser))
+
+	ser, err = json.Marshal(events)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(ser))
+
+	t.Run("unchanged", func(t *testing.T) {
+		data := []byte(`[{"event":"flow_started", "text": "<b>hi</b>"}]`)
+
+		var events Events
+		require.NoError(t, json.Unmarshal(data, &events))
+
+		ser, err := events.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, string(data), string(ser))
+
+		ser, err = json.Marshal(events)
+		require.NoError(t, err)
+		require.Equal(t, `[{"event":"flow_started","text":"\u003cb\u003ehi\u003c/b\u003e"}]`, string(ser))
+		require.JSONEq(t, string(data), string(ser))
+	})
+}
+
+// TestRegisterEventType
+func TestRegisterEventType(t *testing.T) {
+	t.Run("unmarshal", func(t *testing.T) {
+		var events Events
+		err := json.Unmarshal([]byte(`[{"event":"test_custom","value":"test"}]`), &events)
+		require.NoError(t, err)
+		require.Equal(t, Events{&testCustomEvent{Value: "test"}}, events)
+
+		ser, err := json.Marshal(events)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"event":"test_custom","value":"test"}]`, string(ser))
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		ser, err := json.Marshal(&testCustomEvent{
+			Timestamp: Time(time.Unix(1617181920, 500000000)),
+			Value:     "test",
+		})
+		require.NoError(t, err)
+		require.Equal(t, `{"event":"test_custom","timestamp":1617181920.5,"value":"test"}`, string(ser))
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		cases := []struct {
+			data   string
+			expect Event
+		}{
+			{
+				data:   `{"event":"test_embedded","value":1}`,
+				expect: &testEmbeddedEvent{Value: 1},
+			},
+			{
+				data: `{"event":"test_embedded","timestamp":1617181920.5,"source":"test","name":"greet","value":2}`,
+				expect: &testEmbeddedEvent{
+					testEventBase: testEventBase{Timestamp: Time(time.Unix(1617181920, 500000000)), Source: "test"},
+					Intent:        &Intent{Name: "greet"},
+					Value:         2,
+				},
+			},
+		}
+
+		for i := range cases {
+			var events Events
+			err := json.Unmarshal([]byte("["+cases[i].data+"]"), &events)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, Events{cases[i].expect}, events, "failed on %d", i)
+
+			ser, err := json.Marshal(events[0])
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, cases[i].data, string(ser), "failed on %d", i)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		require.Panics(t, func() {
+			RegisterEventType(EventTypeSlotSet, func() Event { return new(SlotSet) })
+		})
+		require.Panics(t, func() {
+			RegisterEventType("test_custom", func() Event { return new(testCustomEvent) })
+		})
+	})
+}
+
+// benchmarkEvents returns a list of n mixed events, as found in a typical
+// tracker.
+func benchmarkEvents(n int) Events {
+	ts := Time(time.Unix(1617181920, 123456000))
+	events := make(Events, 0, n)
+	for len(events) < n {
+		events = append(events,
+			&ActionExecuted{Timestamp: ts, ActionName: ActionListen, Policy: "policy_1_MemoizationPolicy", Confidence: 1},
+			&UserUttered{
+				Timestamp: ts,
+				Text:      "I want to go to Paris",
+				ParseData: &ParseResult{
+					Intent:   Intent{Name: "inform", Confidence: 0.97},
+					Entities: []Entity{{Start: 16, End: 21, Entity: "city", Value: "Paris", Extractor: "DIETClassifier"}},
+					Text:     "I want to go to Paris",
+				},
+				InputChannel: "rest",
+				MessageID:    "4b5c8d2e",
+			},
+			&SlotSet{Timestamp: ts, Key: "city", Value: "Paris"},
+			&BotUttered{Timestamp: ts, Text: "Booking a trip to Paris", Metadata: JSONMap{"utter_action": "utter_booking"}},
+		)
+	}
+	return events[:n]
+}
+
+// BenchmarkEventsMarshal
+func BenchmarkEventsMarshal(b *testing.B) {
+	events := benchmarkEvents(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(events); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEventsUnmarshal
+func BenchmarkEventsUnmarshal(b *testing.B) {
+	data, err := json.Marshal(benchmarkEvents(1000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var events Events
+		if err := json.Unmarshal(data, &events); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -3,7 +3,6 @@ module go.scarlet.dev/rasa
 go 1.13
 
 require (
-	github.com/fatih/structs v1.1.0
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.2.0
 	github.com/spf13/cobra v0.0.7
@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	perrors "github.com/pkg/errors"
+)
+
+// jsonObject writes a JSON object field by field into a single buffer.
+//
+// It is used to marshal events and messages without building intermediate
+// maps. The output matches that of encoding/json, including the escaping of
+// HTML characters in strings.
+type jsonObject struct {
+	buf    []byte
+	fields int
+	err    error
+}
+
+// newJSONObject creates a jsonObject with an initial buffer of the provided
+// capacity.
+func newJSONObject(capacity int) *jsonObject {
+	o := &jsonObject{buf: make([]byte, 0, capacity)}
+	o.buf = append(o.buf, '{')
+	return o
+}
+
+// key writes the separator and the key of the next field.
+func (o *jsonObject) key(name string) {
+	if o.fields > 0 {
+		o.buf = append(o.buf, ',')
+	}
+	o.fields++
+	o.buf = appendJSONString(o.buf, name)
+	o.buf = append(o.buf, ':')
+}
+
+// String writes a string field.
+func (o *jsonObject) String(name, value string) {
+	o.key(name)
+	o.buf = appendJSONString(o.buf, value)
+}
+
+// StringOmitEmpty writes a string field, unless value is empty.
+func (o *jsonObject) StringOmitEmpty(name, value string) {
+	if value != "" {
+		o.String(name, value)
+	}
+}
+
+// Bool writes a boolean field.
+func (o *jsonObject) Bool(name string, value bool) {
+	o.key(name)
+	o.buf = strconv.AppendBool(o.buf, value)
+}
+
+// BoolOmitEmpty writes a boolean field, unless value is false.
+func (o *jsonObject) BoolOmitEmpty(name string, value bool) {
+	if value {
+		o.Bool(name, value)
+	}
+}
+
+// Float writes a number field.
+func (o *jsonObject) Float(name string, value float64) {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		o.fail(perrors.Errorf("unsupported value for field [%s]: %v", name, value))
+		return
+	}
+	o.key(name)
+	o.buf = appendJSONFloat(o.buf, value)
+}
+
+// FloatOmitEmpty writes a number field, unless value is zero.
+func (o *jsonObject) FloatOmitEmpty(name string, value float64) {
+	if value != 0 {
+		o.Float(name, value)
+	}
+}
+
+// Time writes a timestamp field, unless value is the zero time.
+func (o *jsonObject) Time(name string, value Time) {
+	if value.isZero() {
+		return
+	}
+	o.key(name)
+	o.buf = value.appendJSON(o.buf)
+}
+
+// Map writes a free-form object field, unless value is empty.
+func (o *jsonObject) Map(name string, value JSONMap) {
+	if len(value) > 0 {
+		o.Value(name, value)
+	}
+}
+
+// Value writes a field holding any value, using encoding/json.
+func (o *jsonObject) Value(name string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		o.fail(perrors.WithMessagef(err, "unable to marshal field [%s]", name))
+		return
+	}
+	o.Raw(name, data)
+}
+
+// ValueOmitEmpty writes a field holding any value, unless value is nil.
+func (o *jsonObject) ValueOmitEmpty(name string, value interface{}) {
+	if value != nil {
+		o.Value(name, value)
+	}
+}
+
+// Raw writes a field holding pre-marshalled JSON.
+func (o *jsonObject) Raw(name string, data []byte) {
+	o.key(name)
+	o.buf = append(o.buf, data...)
+}
+
+// Bytes closes the object, and returns the result.
+func (o *jsonObject) Bytes() ([]byte, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	return append(o.buf, '}'), nil
+}
+
+// fail records the first error.
+func (o *jsonObject) fail(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+// hex holds the hexadecimal digits used for escaping.
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s to b as a JSON string, escaped the same way as
+// encoding/json.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// appendJSONFloat appends f to b, formatted the same way as encoding/json.
+func appendJSONFloat(b []byte, f float64) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
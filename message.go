@@ -6,12 +6,16 @@
 
 package rasa
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+)
 
 // JSONMap is a descriptive type alias for a free-form JSON Object.
 type JSONMap = map[string]interface{}
 
-// Message TODO
+// Message holds a message sent by the bot, as returned by an action server
+// or sent by Rasa to an output channel.
 type Message struct {
 	// RecipientID is used by Rasa for messages sent over an output channel.
 	RecipientID string `json:"recipient_id,omitempty"`
@@ -31,35 +35,71 @@ type Message struct {
 	// A client should never consult this field. It is used by NLG and
 	// Rasa-internal endpoints to generate responses based on templates when the
 	// template identifiers are returned by a custom action server.
+	//
+	// Deprecated: Rasa 2 renamed templates to responses, use Response.
 	Template string `json:"template,omitempty"`
 
+	// Response holds the name of a response in the domain, which Rasa renders
+	// and sends to the user.
+	Response string `json:"response,omitempty"`
+
 	// Buttons contains a list of clickable buttons that should be rendered by
 	// the UI.
 	Buttons []Button `json:"buttons,omitempty"`
 
-	// Attachment.
-	Attachment string `json:"attachment,omitempty"`
+	// QuickReplies contains a list of quick replies, which are rendered as
+	// buttons that disappear once one of them is clicked.
+	QuickReplies []Button `json:"quick_replies,omitempty"`
+
+	// Attachment holds an attachment payload, which is either the URL of a
+	// file, or an object such as {"type": "video", "payload": {"src": "..."}}.
+	// See Message.AttachmentURL.
+	Attachment interface{} `json:"attachment,omitempty"`
 
 	// Elements.
 	Elements []JSONMap `json:"elements,omitempty"`
 
+	// Custom holds a channel-specific payload, which Rasa passes to the
+	// output channel as is.
+	Custom JSONMap `json:"custom,omitempty"`
+
+	// Channel restricts the message to a single output channel.
+	Channel string `json:"channel,omitempty"`
+
 	// Kwargs holds additional fields at the root level of the object that are
 	// not otherwise provided in the default Message struct.
 	//
-	// Kwargs are used internally by the action server. Prefer using the
-	// JSONMessage field if custom payloads are required to avoid conflicts with
-	// the library.
-	Kwargs JSONMap `json:"-"` // FIXME implement custom ser/de
+	// Unknown fields are collected in Kwargs when a Message is unmarshalled.
+	// Prefer using the Custom field if custom payloads are required to avoid
+	// conflicts with the library.
+	Kwargs JSONMap `json:"-"`
 }
 
 // ensure interfaces.
 var _ json.Marshaler = (*Message)(nil)
-
-// TODO(ed): is Unmarshaler needed? it seems Message is a one-way dto.
-// var _ json.Unmarshaler = (*Message)(nil)
+var _ json.Unmarshaler = (*Message)(nil)
+
+// messageFields holds the names of the fields of Message.
+var messageFields = map[string]bool{
+	"recipient_id":  true,
+	"text":          true,
+	"image":         true,
+	"json_message":  true,
+	"template":      true,
+	"response":      true,
+	"buttons":       true,
+	"quick_replies": true,
+	"attachment":    true,
+	"elements":      true,
+	"custom":        true,
+	"channel":       true,
+}
 
 // WithKwargs adds the free-form kwargs to the m.Kwargs.
 func (m *Message) WithKwargs(kwargs JSONMap) *Message {
+	if m.Kwargs == nil && len(kwargs) > 0 {
+		m.Kwargs = make(JSONMap, len(kwargs))
+	}
 	for key := range kwargs {
 		m.Kwargs[key] = kwargs[key]
 	}
@@ -67,41 +107,121 @@ func (m *Message) WithKwargs(kwargs JSONMap) *Message {
 }
 
 // MarshalJSON implements json.Marshaler.
+//
+// Kwargs are written at the root level of the object, and take precedence
+// over the standard fields with the same name.
 func (m *Message) MarshalJSON() (data []byte, err error) {
 	if m == nil {
-		return
+		return []byte("null"), nil
+	}
+
+	o := newJSONObject(128)
+	has := func(key string) bool {
+		_, ok := m.Kwargs[key]
+		return ok
 	}
 
 	// standard fields
-	raw := make(JSONMap)
-	if m.Text != "" {
-		raw["text"] = m.Text
+	if m.RecipientID != "" && !has("recipient_id") {
+		o.String("recipient_id", m.RecipientID)
+	}
+	if m.Text != "" && !has("text") {
+		o.String("text", m.Text)
+	}
+	if m.Image != "" && !has("image") {
+		o.String("image", m.Image)
+	}
+	if m.JSONMessage != nil && !has("json_message") {
+		o.Value("json_message", m.JSONMessage)
+	}
+	if m.Template != "" && !has("template") {
+		o.String("template", m.Template)
+	}
+	if m.Response != "" && !has("response") {
+		o.String("response", m.Response)
 	}
-	if m.Image != "" {
-		raw["image"] = m.Image
+	if len(m.Buttons) > 0 && !has("buttons") {
+		o.Value("buttons", m.Buttons)
 	}
-	if m.JSONMessage != nil {
-		raw["json_message"] = m.JSONMessage
+	if len(m.QuickReplies) > 0 && !has("quick_replies") {
+		o.Value("quick_replies", m.QuickReplies)
 	}
-	if m.Template != "" {
-		raw["template"] = m.Template
+	if m.Attachment != nil && m.Attachment != "" && !has("attachment") {
+		o.Value("attachment", m.Attachment)
 	}
-	if m.Attachment != "" {
-		raw["attachment"] = m.Attachment
+	if len(m.Elements) > 0 && !has("elements") {
+		o.Value("elements", m.Elements)
 	}
-	if len(m.Buttons) > 0 {
-		raw["buttons"] = m.Buttons
+	if len(m.Custom) > 0 && !has("custom") {
+		o.Value("custom", m.Custom)
 	}
-	if len(m.Elements) > 0 {
-		raw["elements"] = m.Elements
+	if m.Channel != "" && !has("channel") {
+		o.String("channel", m.Channel)
 	}
 
-	// copy KWargs
+	// copy KWargs, sorted for a stable output
+	keys := make([]string, 0, len(m.Kwargs))
 	for key := range m.Kwargs {
-		raw[key] = m.Kwargs[key]
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o.Value(key, m.Kwargs[key])
+	}
+
+	return o.Bytes()
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// Fields which are not part of the Message struct are collected in Kwargs.
+func (m *Message) UnmarshalJSON(data []byte) (err error) {
+	type alias Message
+	var msg alias
+	if err = json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+	for key := range fields {
+		if messageFields[key] {
+			continue
+		}
+		if msg.Kwargs == nil {
+			msg.Kwargs = make(JSONMap)
+		}
+
+		var value interface{}
+		if err = json.Unmarshal(fields[key], &value); err != nil {
+			return
+		}
+		msg.Kwargs[key] = value
+	}
+
+	*m = Message(msg)
+	return
+}
 
-	return json.Marshal(raw)
+// AttachmentURL returns the URL of the attachment, which is either the
+// attachment itself, or the "src" or "url" of the payload of an attachment
+// object. An empty string is returned if the attachment has no URL.
+func (m *Message) AttachmentURL() string {
+	switch attachment := m.Attachment.(type) {
+	case string:
+		return attachment
+	case JSONMap:
+		if payload, ok := attachment["payload"].(JSONMap); ok {
+			for _, key := range []string{"src", "url"} {
+				if url, ok := payload[key].(string); ok && url != "" {
+					return url
+				}
+			}
+		}
+	}
+	return ""
 }
 
 // Button defines the structure of a Button response.
@@ -112,4 +232,18 @@ type Button struct {
 	Title string `json:"title"`
 	// Payload holds the payload being sent if the button is pressed.
 	Payload string `json:"payload"`
+	// Type optionally holds the type of the button, such as "postback" or
+	// "web_url" for the Facebook channel.
+	Type string `json:"type,omitempty"`
+	// URL holds the URL opened by buttons of type "web_url".
+	URL string `json:"url,omitempty"`
 }
+
+// Button types supported by the channels of Rasa.
+const (
+	// ButtonTypePostback sends the payload of the button as a user message.
+	ButtonTypePostback = "postback"
+
+	// ButtonTypeWebURL opens the URL of the button.
+	ButtonTypeWebURL = "web_url"
+)
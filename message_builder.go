@@ -0,0 +1,216 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+
+	perrors "github.com/pkg/errors"
+)
+
+// MessageBuilder builds a Message by chaining method calls.
+//
+//	msg := rasa.NewMessage().
+//		Text("Where would you like to go?").
+//		IntentButton("Paris", "inform", rasa.JSONMap{"city": "Paris"}).
+//		IntentButton("Rome", "inform", rasa.JSONMap{"city": "Rome"}).
+//		Build()
+type MessageBuilder struct {
+	msg Message
+}
+
+// NewMessage creates a new MessageBuilder.
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{}
+}
+
+// Text sets the text of the message.
+func (b *MessageBuilder) Text(text string) *MessageBuilder {
+	b.msg.Text = text
+	return b
+}
+
+// Image sets the URL of the image of the message.
+func (b *MessageBuilder) Image(url string) *MessageBuilder {
+	b.msg.Image = url
+	return b
+}
+
+// Attachment sets the attachment of the message, which is either the URL of
+// a file or an attachment object. See Message.Attachment.
+func (b *MessageBuilder) Attachment(attachment interface{}) *MessageBuilder {
+	b.msg.Attachment = attachment
+	return b
+}
+
+// Response sets the name of the domain response to send.
+func (b *MessageBuilder) Response(name string) *MessageBuilder {
+	b.msg.Response = name
+	return b
+}
+
+// Channel restricts the message to a single output channel.
+func (b *MessageBuilder) Channel(channel string) *MessageBuilder {
+	b.msg.Channel = channel
+	return b
+}
+
+// Custom sets the channel-specific payload of the message.
+func (b *MessageBuilder) Custom(payload JSONMap) *MessageBuilder {
+	b.msg.Custom = payload
+	return b
+}
+
+// Kwarg adds a field at the root level of the message. For responses, the
+// kwargs are used to fill the placeholders of the response.
+func (b *MessageBuilder) Kwarg(key string, value interface{}) *MessageBuilder {
+	b.msg.WithKwargs(JSONMap{key: value})
+	return b
+}
+
+// Button adds a button which sends the payload when clicked.
+func (b *MessageBuilder) Button(title, payload string) *MessageBuilder {
+	b.msg.Buttons = append(b.msg.Buttons, Button{Title: title, Payload: payload})
+	return b
+}
+
+// IntentButton adds a button which triggers the intent with the entities
+// when clicked.
+func (b *MessageBuilder) IntentButton(title, intent string, entities JSONMap) *MessageBuilder {
+	return b.Button(title, IntentPayload{Intent: intent, Entities: entities}.String())
+}
+
+// URLButton adds a button which opens the URL when clicked.
+func (b *MessageBuilder) URLButton(title, url string) *MessageBuilder {
+	b.msg.Buttons = append(b.msg.Buttons, URLButton(title, url))
+	return b
+}
+
+// QuickReply adds a quick reply which sends the payload when clicked.
+func (b *MessageBuilder) QuickReply(title, payload string) *MessageBuilder {
+	b.msg.QuickReplies = append(b.msg.QuickReplies, Button{Title: title, Payload: payload})
+	return b
+}
+
+// Carousel adds the elements to the message, which channels such as
+// Facebook render as a carousel.
+func (b *MessageBuilder) Carousel(elements ...Element) *MessageBuilder {
+	for i := range elements {
+		b.msg.Elements = append(b.msg.Elements, elements[i].toMap())
+	}
+	return b
+}
+
+// Build returns the message. The builder should not be used afterwards.
+func (b *MessageBuilder) Build() *Message {
+	msg := b.msg
+	return &msg
+}
+
+// URLButton returns a button which opens the URL when clicked.
+func URLButton(title, url string) Button {
+	return Button{Title: title, Type: ButtonTypeWebURL, URL: url}
+}
+
+// Element holds an element of a carousel, following the generic template
+// used by Rasa's Facebook channel, which is also its JSON representation.
+type Element struct {
+	// Title holds the title of the element.
+	Title string
+
+	// Subtitle holds the text shown below the title.
+	Subtitle string
+
+	// ImageURL holds the URL of the image of the element.
+	ImageURL string
+
+	// DefaultURL holds the URL opened when the element is clicked.
+	DefaultURL string
+
+	// Buttons holds the buttons of the element.
+	Buttons []Button
+}
+
+// elementJSON is the JSON representation of an Element.
+type elementJSON struct {
+	Title         string   `json:"title"`
+	Subtitle      string   `json:"subtitle"`
+	ImageURL      string   `json:"image_url"`
+	DefaultAction *Button  `json:"default_action"`
+	Buttons       []Button `json:"buttons"`
+}
+
+// ensure interface
+var (
+	_ json.Marshaler   = Element{}
+	_ json.Unmarshaler = (*Element)(nil)
+)
+
+// MarshalJSON implements json.Marshaler.
+func (e Element) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toMap())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Element) UnmarshalJSON(data []byte) error {
+	var result elementJSON
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*e = Element{
+		Title:    result.Title,
+		Subtitle: result.Subtitle,
+		ImageURL: result.ImageURL,
+		Buttons:  result.Buttons,
+	}
+	if result.DefaultAction != nil {
+		e.DefaultURL = result.DefaultAction.URL
+	}
+	return nil
+}
+
+// CarouselElements returns the elements of the message, as added by
+// MessageBuilder.Carousel or received from Rasa. An error is returned if the
+// elements do not follow the generic template.
+func (m *Message) CarouselElements() ([]Element, error) {
+	if len(m.Elements) == 0 {
+		return nil, nil
+	}
+
+	ser, err := json.Marshal(m.Elements)
+	if err != nil {
+		return nil, perrors.WithMessage(err, "could not marshal the elements")
+	}
+
+	var result []Element
+	if err := json.Unmarshal(ser, &result); err != nil {
+		return nil, perrors.WithMessage(err, "invalid elements")
+	}
+	return result, nil
+}
+
+// toMap converts the element into the JSONMap used by Message.Elements.
+func (e *Element) toMap() JSONMap {
+	m := JSONMap{"title": e.Title}
+	if e.Subtitle != "" {
+		m["subtitle"] = e.Subtitle
+	}
+	if e.ImageURL != "" {
+		m["image_url"] = e.ImageURL
+	}
+	if e.DefaultURL != "" {
+		m["default_action"] = JSONMap{
+			"type": ButtonTypeWebURL,
+			"url":  e.DefaultURL,
+		}
+	}
+	if len(e.Buttons) > 0 {
+		m["buttons"] = e.Buttons
+	}
+	return m
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMessageBuilder
+func TestMessageBuilder(t *testing.T) {
+	cases := []struct {
+		msg    *Message
+		expect string
+	}{
+		{
+			msg: NewMessage().
+				Text("Where to?").
+				IntentButton("Paris", "inform", JSONMap{"city": "Paris"}).
+				Button("Nowhere", "/deny").
+				URLButton("Map", "https://example.com/map").
+				Build(),
+			expect: `{
+				"text": "Where to?",
+				"buttons": [
+					{"title": "Paris", "payload": "/inform{\"city\":\"Paris\"}"},
+					{"title": "Nowhere", "payload": "/deny"},
+					{"title": "Map", "payload": "", "type": "web_url", "url": "https://example.com/map"}
+				]
+			}`,
+		},
+		{
+			msg: NewMessage().
+				Text("Are you sure?").
+				QuickReply("Yes", "/affirm").
+				QuickReply("No", "/deny").
+				Build(),
+			expect: `{
+				"text": "Are you sure?",
+				"quick_replies": [
+					{"title": "Yes", "payload": "/affirm"},
+					{"title": "No", "payload": "/deny"}
+				]
+			}`,
+		},
+		{
+			msg: NewMessage().Carousel(
+				Element{
+					Title:      "Paris",
+					Subtitle:   "City of light",
+					ImageURL:   "https://example.com/paris.png",
+					DefaultURL: "https://example.com/paris",
+					Buttons:    []Button{{Title: "Book", Payload: "/book"}},
+				},
+				Element{Title: "Rome"},
+			).Build(),
+			expect: `{
+				"elements": [
+					{
+						"title": "Paris",
+						"subtitle": "City of light",
+						"image_url": "https://example.com/paris.png",
+						"default_action": {"type": "web_url", "url": "https://example.com/paris"},
+						"buttons": [{"title": "Book", "payload": "/book"}]
+					},
+					{"title": "Rome"}
+				]
+			}`,
+		},
+		{
+			msg: NewMessage().
+				Response("utter_greet").
+				Kwarg("name", "Eddy").
+				Channel("slack").
+				Build(),
+			expect: `{"response": "utter_greet", "channel": "slack", "name": "Eddy"}`,
+		},
+		{
+			msg: NewMessage().
+				Image("https://example.com/a.png").
+				Attachment("https://example.com/a.pdf").
+				Custom(JSONMap{"blocks": []interface{}{}}).
+				Build(),
+			expect: `{"image": "https://example.com/a.png", "attachment": "https://example.com/a.pdf", "custom": {"blocks": []}}`,
+		},
+	}
+
+	for i := range cases {
+		ser, err := json.Marshal(cases[i].msg)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.JSONEqf(t, cases[i].expect, string(ser), "failed on %d", i)
+	}
+
+	t.Run("Build", func(t *testing.T) {
+		builder := NewMessage().Text("a")
+		msg := builder.Build()
+		builder.Text("b")
+		require.Equal(t, "a", msg.Text)
+	})
+}
+
+// TestMessageCarouselElements
+func TestMessageCarouselElements(t *testing.T) {
+	elements := []Element{
+		{
+			Title:      "Paris",
+			Subtitle:   "City of light",
+			ImageURL:   "https://example.com/paris.png",
+			DefaultURL: "https://example.com/paris",
+			Buttons:    []Button{{Title: "Book", Payload: "/book"}},
+		},
+		{Title: "Rome"},
+	}
+
+	cases := []struct {
+		msg    *Message
+		expect []Element
+		err    bool
+	}{
+		{msg: NewMessage().Text("Hi").Build()},
+		{msg: NewMessage().Carousel(elements...).Build(), expect: elements},
+		{
+			// elements received from Rasa
+			msg: &Message{Elements: []JSONMap{{
+				"title":          "Rome",
+				"default_action": JSONMap{"type": "web_url", "url": "https://example.com/rome"},
+			}}},
+			expect: []Element{{Title: "Rome", DefaultURL: "https://example.com/rome"}},
+		},
+		{msg: &Message{Elements: []JSONMap{{"title": 1}}}, err: true},
+	}
+
+	for i := range cases {
+		result, err := cases[i].msg.CarouselElements()
+		if cases[i].err {
+			require.Errorf(t, err, "failed on %d", i)
+			continue
+		}
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.Equalf(t, cases[i].expect, result, "failed on %d", i)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMessage
+func TestMessage(t *testing.T) {
+	t.Run("JSON marshal", func(t *testing.T) {
+		cases := []struct {
+			msg    *Message
+			expect string
+		}{
+			{
+				msg:    &Message{},
+				expect: `{}`,
+			},
+			{
+				msg: &Message{
+					Text:    "Where to?",
+					Buttons: []Button{{Title: "Paris", Payload: "/inform"}},
+				},
+				expect: `{"text":"Where to?","buttons":[{"title":"Paris","payload":"/inform"}]}`,
+			},
+			{
+				msg:    &Message{RecipientID: "user", Text: "Hi"},
+				expect: `{"recipient_id":"user","text":"Hi"}`,
+			},
+			{
+				// kwargs override the standard fields
+				msg: &Message{
+					Text:   "Hello",
+					Kwargs: JSONMap{"text": "Hi", "b": 2, "a": 1},
+				},
+				expect: `{"a":1,"b":2,"text":"Hi"}`,
+			},
+		}
+
+		for i := range cases {
+			ser, err := json.Marshal(cases[i].msg)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, cases[i].expect, string(ser), "failed on %d", i)
+		}
+	})
+
+	t.Run("JSON round trip", func(t *testing.T) {
+		cases := []string{
+			`{"recipient_id":"user","text":"Hi"}`,
+			`{"text":"Where to?","buttons":[{"title":"Paris","payload":"/inform","type":"postback"}]}`,
+			`{"text":"Pick one","quick_replies":[{"title":"Yes","payload":"/affirm"}]}`,
+			`{"response":"utter_greet","template":"utter_greet"}`,
+			`{"custom":{"blocks":[{"type":"section"}]},"channel":"slack"}`,
+			`{"image":"https://example.com/a.png","attachment":"https://example.com/a.pdf"}`,
+			`{"text":"Watch","attachment":{"type":"video","payload":{"title":"Intro","src":"https://example.com/a.mp4"}}}`,
+			`{"elements":[{"title":"Paris"}],"json_message":{"a":1}}`,
+			`{"text":"Hi","name":"Eddy","count":1}`,
+		}
+
+		for i := range cases {
+			var msg Message
+			require.NoErrorf(t, json.Unmarshal([]byte(cases[i]), &msg), "failed on %d", i)
+
+			ser, err := json.Marshal(&msg)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.JSONEqf(t, cases[i], string(ser), "failed on %d", i)
+		}
+	})
+
+	t.Run("JSON unmarshal kwargs", func(t *testing.T) {
+		var msg Message
+		require.NoError(t, json.Unmarshal([]byte(`{"text":"Hi","name":"Eddy","nested":{"a":[1]}}`), &msg))
+		require.Equal(t, Message{
+			Text:   "Hi",
+			Kwargs: JSONMap{"name": "Eddy", "nested": map[string]interface{}{"a": []interface{}{1.0}}},
+		}, msg)
+
+		require.NoError(t, json.Unmarshal([]byte(`{"text":"Hi"}`), &msg))
+		require.Nil(t, msg.Kwargs)
+		require.Error(t, json.Unmarshal([]byte(`{"text":1}`), &msg))
+	})
+
+	t.Run("AttachmentURL", func(t *testing.T) {
+		cases := []struct {
+			attachment interface{}
+			expect     string
+		}{
+			{attachment: nil, expect: ""},
+			{attachment: "https://example.com/a.pdf", expect: "https://example.com/a.pdf"},
+			{attachment: JSONMap{"type": "video", "payload": JSONMap{"src": "https://example.com/a.mp4"}}, expect: "https://example.com/a.mp4"},
+			{attachment: JSONMap{"type": "image", "payload": JSONMap{"url": "https://example.com/a.png"}}, expect: "https://example.com/a.png"},
+			{attachment: JSONMap{"type": "template", "payload": JSONMap{"template_type": "generic"}}, expect: ""},
+		}
+
+		for i := range cases {
+			msg := &Message{Attachment: cases[i].attachment}
+			require.Equalf(t, cases[i].expect, msg.AttachmentURL(), "failed on %d", i)
+		}
+	})
+
+	t.Run("WithKwargs", func(t *testing.T) {
+		msg := (&Message{Text: "Hi"}).WithKwargs(JSONMap{"name": "Eddy"})
+		require.Equal(t, JSONMap{"name": "Eddy"}, msg.Kwargs)
+
+		msg.WithKwargs(JSONMap{"count": 1})
+		require.Equal(t, JSONMap{"name": "Eddy", "count": 1}, msg.Kwargs)
+	})
+}
+
+// BenchmarkMessageMarshal
+func BenchmarkMessageMarshal(b *testing.B) {
+	msg := &Message{
+		Text:  "Which city would you like to visit?",
+		Image: "https://example.com/cities.png",
+		Buttons: []Button{
+			{Title: "Paris", Payload: `/inform{"city":"Paris"}`},
+			{Title: "Rome", Payload: `/inform{"city":"Rome"}`},
+		},
+		Kwargs: JSONMap{"utter_action": "utter_ask_city"},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
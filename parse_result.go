@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"sort"
+)
+
+// Names of the entity extractors provided by Rasa.
+const (
+	ExtractorCRF      = "CRFEntityExtractor"
+	ExtractorDIET     = "DIETClassifier"
+	ExtractorDuckling = "DucklingEntityExtractor"
+	ExtractorMitie    = "MitieEntityExtractor"
+	ExtractorRegex    = "RegexEntityExtractor"
+	ExtractorSpacy    = "SpacyEntityExtractor"
+	ExtractorSynonyms = "EntitySynonymMapper"
+)
+
+// Ranking returns the ranking of the intents by descending confidence. If
+// the NLU pipeline does not provide a ranking, the ranking only holds the
+// predicted intent.
+//
+// The returned slice is a copy, and can be modified by the caller.
+func (p *ParseResult) Ranking() []Intent {
+	if len(p.IntentRanking) == 0 {
+		if p.Intent.Name == "" {
+			return nil
+		}
+		return []Intent{p.Intent}
+	}
+
+	ranking := make([]Intent, len(p.IntentRanking))
+	copy(ranking, p.IntentRanking)
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Confidence > ranking[j].Confidence
+	})
+	return ranking
+}
+
+// TopIntents returns up to n intents with the highest confidence, or no
+// intents if n is not positive.
+func (p *ParseResult) TopIntents(n int) []Intent {
+	if n <= 0 {
+		return nil
+	}
+
+	ranking := p.Ranking()
+	if len(ranking) > n {
+		ranking = ranking[:n]
+	}
+	return ranking
+}
+
+// IntentsAbove returns the ranked intents with a confidence of at least the
+// threshold.
+func (p *ParseResult) IntentsAbove(threshold float64) []Intent {
+	ranking := p.Ranking()
+	for i := range ranking {
+		if ranking[i].Confidence < threshold {
+			return ranking[:i]
+		}
+	}
+	return ranking
+}
+
+// IsConfident returns whether the confidence of the predicted intent is at
+// least the threshold. The nlu_fallback intent is never confident.
+func (p *ParseResult) IsConfident(threshold float64) bool {
+	return p.Intent.Name != "" &&
+		p.Intent.Name != IntentNLUFallback &&
+		p.Intent.Confidence >= threshold
+}
+
+// IsAmbiguous returns whether the difference between the confidences of the
+// two highest ranked intents is less than the gap, which corresponds to the
+// ambiguity_threshold of Rasa's FallbackClassifier.
+//
+// The nlu_fallback intent is ignored, as the FallbackClassifier adds it to
+// the top of the ranking.
+func (p *ParseResult) IsAmbiguous(gap float64) bool {
+	ranking := withoutIntents(p.Ranking(), IntentNLUFallback)
+	if len(ranking) < 2 {
+		return false
+	}
+	return ranking[0].Confidence-ranking[1].Confidence < gap
+}
+
+// Disambiguation holds the options for ParseResult.DisambiguationButtons.
+type Disambiguation struct {
+	// Count holds the maximum number of intents to suggest. Defaults to 2.
+	Count int
+
+	// Threshold holds the minimum confidence of the suggested intents.
+	Threshold float64
+
+	// Exclude holds the names of intents which are never suggested. The
+	// nlu_fallback intent is always excluded.
+	Exclude []string
+
+	// Title returns the title of the button for the intent, such as the text
+	// of the `utter_ask_<intent>` response. Defaults to the intent name.
+	Title func(intent string) string
+
+	// FallbackTitle holds the title of the button added after the
+	// suggestions, such as "Something else". No button is added if empty.
+	FallbackTitle string
+
+	// FallbackIntent holds the intent triggered by the fallback button.
+	// Defaults to "out_of_scope".
+	FallbackIntent string
+}
+
+// DisambiguationButtons returns buttons for asking the user which of the
+// highest ranked intents they meant, as done by Rasa's two-stage fallback.
+//
+// The payload of every button triggers the intent with the entities of the
+// message, so the user does not have to repeat them.
+func (p *ParseResult) DisambiguationButtons(opts Disambiguation) []Button {
+	if opts.Count <= 0 {
+		opts.Count = 2
+	}
+	if opts.FallbackIntent == "" {
+		opts.FallbackIntent = "out_of_scope"
+	}
+
+	exclude := make([]string, 0, len(opts.Exclude)+1)
+	exclude = append(exclude, opts.Exclude...)
+	exclude = append(exclude, IntentNLUFallback)
+
+	entities := p.entityValues()
+	ranking := withoutIntents(p.IntentsAbove(opts.Threshold), exclude...)
+	if len(ranking) > opts.Count {
+		ranking = ranking[:opts.Count]
+	}
+
+	buttons := make([]Button, 0, len(ranking)+1)
+	for i := range ranking {
+		title := ranking[i].Name
+		if opts.Title != nil {
+			title = opts.Title(ranking[i].Name)
+		}
+		buttons = append(buttons, Button{
+			Title:   title,
+			Payload: IntentPayload{Intent: ranking[i].Name, Entities: entities}.String(),
+		})
+	}
+	if opts.FallbackTitle != "" {
+		buttons = append(buttons, Button{
+			Title:   opts.FallbackTitle,
+			Payload: IntentPayload{Intent: opts.FallbackIntent}.String(),
+		})
+	}
+	return buttons
+}
+
+// entityValues returns the values of the entities by name. If an entity was
+// extracted more than once, the first value is kept.
+func (p *ParseResult) entityValues() JSONMap {
+	if len(p.Entities) == 0 {
+		return nil
+	}
+
+	values := make(JSONMap, len(p.Entities))
+	for i := range p.Entities {
+		if _, exists := values[p.Entities[i].Entity]; !exists {
+			values[p.Entities[i].Entity] = p.Entities[i].Value
+		}
+	}
+	return values
+}
+
+// EntitiesFrom returns the entities extracted by any of the extractors.
+func (p *ParseResult) EntitiesFrom(extractors ...string) []Entity {
+	return p.filterEntities(func(e *Entity) bool {
+		return sliceContains(extractors, e.Extractor)
+	})
+}
+
+// EntitiesAbove returns the entities with a confidence of at least the
+// threshold. See Entity.Score.
+func (p *ParseResult) EntitiesAbove(threshold float64) []Entity {
+	return p.filterEntities(func(e *Entity) bool {
+		return e.Score() >= threshold
+	})
+}
+
+// filterEntities returns the entities for which fn returns true.
+func (p *ParseResult) filterEntities(fn func(e *Entity) bool) (entities []Entity) {
+	for i := range p.Entities {
+		if fn(&p.Entities[i]) {
+			entities = append(entities, p.Entities[i])
+		}
+	}
+	return
+}
+
+// Score returns the confidence of the entity, regardless of the field in
+// which the extractor stores it. Entities extracted without a confidence,
+// such as by the RegexEntityExtractor, have a score of 1. A confidence of
+// zero is only considered to be present if it was unmarshalled from JSON.
+func (e *Entity) Score() float64 {
+	switch {
+	case e.hasConfidenceEntity || e.ConfidenceEntity != 0:
+		return e.ConfidenceEntity
+	case e.hasConfidence || e.Confidence != 0:
+		return e.Confidence
+	}
+	return 1
+}
+
+// withoutIntents returns the intents whose name is not in names. The intents
+// are filtered in place.
+func withoutIntents(intents []Intent, names ...string) []Intent {
+	result := intents[:0]
+	for i := range intents {
+		if !sliceContains(names, intents[i].Name) {
+			result = append(result, intents[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testParseResult returns the result of an ambiguous message, as classified
+// by the FallbackClassifier.
+func testParseResult() *ParseResult {
+	return &ParseResult{
+		Text:   "book a table in Paris",
+		Intent: Intent{Name: IntentNLUFallback, Confidence: 0.7},
+		IntentRanking: []Intent{
+			{Name: "book_hotel", Confidence: 0.41},
+			{Name: IntentNLUFallback, Confidence: 0.7},
+			{Name: "book_restaurant", Confidence: 0.45},
+			{Name: "greet", Confidence: 0.1},
+		},
+		Entities: []Entity{
+			{Entity: "city", Value: "Paris", Extractor: ExtractorDIET, ConfidenceEntity: 0.98},
+			{Entity: "city", Value: "paris", Extractor: ExtractorRegex},
+			{Entity: "number", Value: 1.0, Extractor: ExtractorDuckling, Confidence: 1},
+			{Entity: "time", Value: "now", Extractor: ExtractorDIET, ConfidenceEntity: 0.4},
+		},
+	}
+}
+
+// TestParseResultRanking
+func TestParseResultRanking(t *testing.T) {
+	p := testParseResult()
+
+	require.Equal(t, []Intent{
+		{Name: IntentNLUFallback, Confidence: 0.7},
+		{Name: "book_restaurant", Confidence: 0.45},
+		{Name: "book_hotel", Confidence: 0.41},
+		{Name: "greet", Confidence: 0.1},
+	}, p.Ranking())
+	require.Equal(t, "book_hotel", p.IntentRanking[0].Name, "ranking must not be modified")
+
+	require.Len(t, p.TopIntents(2), 2)
+	require.Len(t, p.TopIntents(10), 4)
+	require.Empty(t, p.TopIntents(0))
+	require.Empty(t, p.TopIntents(-1))
+	require.Len(t, p.IntentsAbove(0.4), 3)
+	require.Len(t, p.IntentsAbove(0.9), 0)
+
+	require.False(t, p.IsConfident(0.5))
+	require.True(t, p.IsAmbiguous(0.1))
+	require.False(t, p.IsAmbiguous(0.01))
+
+	single := &ParseResult{Intent: Intent{Name: "greet", Confidence: 0.9}}
+	require.Equal(t, []Intent{single.Intent}, single.Ranking())
+	require.True(t, single.IsConfident(0.9))
+	require.False(t, single.IsConfident(0.95))
+	require.False(t, single.IsAmbiguous(1))
+
+	require.Nil(t, (&ParseResult{}).Ranking())
+}
+
+// TestParseResultDisambiguation
+func TestParseResultDisambiguation(t *testing.T) {
+	p := testParseResult()
+
+	cases := []struct {
+		opts   Disambiguation
+		expect []Button
+	}{
+		{
+			expect: []Button{
+				{Title: "book_restaurant", Payload: `/book_restaurant{"city":"Paris","number":1,"time":"now"}`},
+				{Title: "book_hotel", Payload: `/book_hotel{"city":"Paris","number":1,"time":"now"}`},
+			},
+		},
+		{
+			opts: Disambiguation{
+				Count:         3,
+				Threshold:     0.2,
+				Exclude:       []string{"book_hotel"},
+				Title:         func(intent string) string { return "Did you mean " + intent + "?" },
+				FallbackTitle: "Something else",
+			},
+			expect: []Button{
+				{Title: "Did you mean book_restaurant?", Payload: `/book_restaurant{"city":"Paris","number":1,"time":"now"}`},
+				{Title: "Something else", Payload: `/out_of_scope`},
+			},
+		},
+		{
+			opts:   Disambiguation{Threshold: 0.9, FallbackTitle: "None", FallbackIntent: "deny"},
+			expect: []Button{{Title: "None", Payload: `/deny`}},
+		},
+	}
+
+	for i := range cases {
+		require.Equalf(t, cases[i].expect, p.DisambiguationButtons(cases[i].opts), "failed on %d", i)
+	}
+}
+
+// TestParseResultEntities
+func TestParseResultEntities(t *testing.T) {
+	p := testParseResult()
+
+	require.Len(t, p.EntitiesFrom(ExtractorDIET), 2)
+	require.Len(t, p.EntitiesFrom(ExtractorRegex, ExtractorDuckling), 2)
+	require.Len(t, p.EntitiesFrom(ExtractorSpacy), 0)
+
+	above := p.EntitiesAbove(0.5)
+	require.Len(t, above, 3)
+	for i := range above {
+		require.NotEqualf(t, "time", above[i].Entity, "failed on %d", i)
+	}
+
+	require.Equal(t, 0.98, p.Entities[0].Score())
+	require.Equal(t, 1.0, p.Entities[1].Score())
+	require.Equal(t, 0.4, p.Entities[3].Score())
+}
+
+// TestEntityScore
+func TestEntityScore(t *testing.T) {
+	cases := []struct {
+		data   string
+		expect float64
+	}{
+		{data: `{"entity":"city"}`, expect: 1},
+		{data: `{"entity":"city","confidence_entity":0.9}`, expect: 0.9},
+		{data: `{"entity":"city","confidence_entity":0}`, expect: 0},
+		{data: `{"entity":"city","confidence":0,"confidence_entity":0.8}`, expect: 0.8},
+		{data: `{"entity":"city","confidence":0}`, expect: 0},
+	}
+
+	for i := range cases {
+		var e Entity
+		require.NoErrorf(t, json.Unmarshal([]byte(cases[i].data), &e), "failed on %d", i)
+		require.Equalf(t, cases[i].expect, e.Score(), "failed on %d", i)
+
+		// the confidences are retained
+		ser, err := json.Marshal(e)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.JSONEqf(t, `{"start":0,"end":0,"value":null,`+cases[i].data[1:], string(ser), "failed on %d", i)
+	}
+}
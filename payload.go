@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+)
+
+// IntentPayload holds an intent and its entities, which Rasa recognizes
+// without NLU when sent as a message such as `/inform{"city":"Paris"}`.
+//
+// Intent payloads are typically used as the payload of buttons.
+type IntentPayload struct {
+	// Intent holds the name of the intent.
+	Intent string
+
+	// Confidence holds the confidence of the intent. Zero means the
+	// confidence is not specified, in which case Rasa uses 1.
+	Confidence float64
+
+	// Entities holds the values of the entities, by name.
+	Entities JSONMap
+}
+
+// intentPayloadPattern matches intent payloads the same way as Rasa's
+// RegexMessageHandler.
+var intentPayloadPattern = regexp.MustCompile(`^/([^{@]+)(@[0-9.]+)?(\{.+\})?$`)
+
+// String returns the payload as sent to Rasa. The entities are serialized
+// with sorted keys.
+func (p IntentPayload) String() string {
+	var b strings.Builder
+	b.WriteByte('/')
+	b.WriteString(p.Intent)
+	if p.Confidence != 0 {
+		b.WriteByte('@')
+		b.WriteString(strconv.FormatFloat(p.Confidence, 'f', -1, 64))
+	}
+	if len(p.Entities) > 0 {
+		// a JSONMap of JSON values can always be marshalled
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(p.Entities); err == nil {
+			b.Write(bytes.TrimSpace(buf.Bytes()))
+		}
+	}
+	return b.String()
+}
+
+// ParseIntentPayload parses an intent payload such as
+// `/inform@0.9{"city":"Paris"}`.
+func ParseIntentPayload(payload string) (p IntentPayload, err error) {
+	match := intentPayloadPattern.FindStringSubmatch(strings.TrimSpace(payload))
+	if match == nil {
+		err = perrors.Errorf("invalid intent payload [%s]", payload)
+		return
+	}
+
+	p.Intent = match[1]
+	if match[2] != "" {
+		if p.Confidence, err = strconv.ParseFloat(match[2][1:], 64); err != nil {
+			err = perrors.WithMessagef(err, "invalid confidence in intent payload [%s]", payload)
+			return
+		}
+	}
+	if match[3] != "" {
+		if err = json.Unmarshal([]byte(match[3]), &p.Entities); err != nil {
+			err = perrors.WithMessagef(err, "invalid entities in intent payload [%s]", payload)
+			return
+		}
+	}
+	return
+}
+
+// IsIntentPayload returns whether the text of a message is an intent payload.
+func IsIntentPayload(text string) bool {
+	return intentPayloadPattern.MatchString(strings.TrimSpace(text))
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestIntentPayload
+func TestIntentPayload(t *testing.T) {
+	cases := []struct {
+		payload IntentPayload
+		str     string
+	}{
+		{IntentPayload{Intent: "greet"}, "/greet"},
+		{IntentPayload{Intent: "inform", Entities: JSONMap{"city": "Paris"}}, `/inform{"city":"Paris"}`},
+		{IntentPayload{Intent: "inform", Entities: JSONMap{"to": "Rome", "from": "Paris"}}, `/inform{"from":"Paris","to":"Rome"}`},
+		{IntentPayload{Intent: "inform", Entities: JSONMap{"q": "a&b"}}, `/inform{"q":"a&b"}`},
+		{IntentPayload{Intent: "affirm", Confidence: 0.9}, "/affirm@0.9"},
+		{IntentPayload{Intent: "book", Confidence: 0.5, Entities: JSONMap{"travellers": 2.0}}, `/book@0.5{"travellers":2}`},
+	}
+
+	for i := range cases {
+		entry := cases[i]
+		require.Equalf(t, entry.str, entry.payload.String(), "failed on %d", i)
+		require.Truef(t, IsIntentPayload(entry.str), "failed on %d", i)
+
+		parsed, err := ParseIntentPayload(entry.str)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.Equalf(t, entry.payload, parsed, "failed on %d", i)
+	}
+
+	for _, invalid := range []string{"", "greet", "/", `/inform{"city":}`, "/inform@x"} {
+		_, err := ParseIntentPayload(invalid)
+		require.Errorf(t, err, "failed on %s", invalid)
+	}
+	require.False(t, IsIntentPayload("hello"))
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package rasatest provides assertions for testing action handlers, in the
+// style of testify's require package.
+//
+// The assertions report the differences between trackers and events in a
+// human-readable form instead of dumping the values:
+//
+//	rasatest.EqualEvents(t, expected, events)
+package rasatest
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasatest
+
+import (
+	"fmt"
+
+	"go.scarlet.dev/rasa"
+)
+
+// TestingT is the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+// tHelper is implemented by testing.TB, to exclude the assertions from the
+// reported location of failures.
+type tHelper interface {
+	Helper()
+}
+
+// EqualTrackers asserts that the trackers are equal according to
+// rasa.DiffTrackers, and fails the test immediately otherwise.
+func EqualTrackers(t TestingT, expected, actual *rasa.Tracker, msgAndArgs ...interface{}) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	diff := rasa.DiffTrackers(expected, actual)
+	if diff.Empty() {
+		return
+	}
+	fail(t, "trackers differ:\n"+diff.String(), msgAndArgs...)
+}
+
+// EqualEvents asserts that the events are equal according to
+// rasa.DiffEvents, and fails the test immediately otherwise.
+func EqualEvents(t TestingT, expected, actual rasa.Events, msgAndArgs ...interface{}) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	changes := rasa.DiffEvents(expected, actual)
+	if len(changes) == 0 {
+		return
+	}
+	fail(t, "events differ:\n"+rasa.FormatEventChanges(changes), msgAndArgs...)
+}
+
+// fail reports the failure with the optional message, and stops the test.
+func fail(t TestingT, report string, msgAndArgs ...interface{}) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if msg := formatMessage(msgAndArgs...); msg != "" {
+		report = msg + "\n" + report
+	}
+	t.Errorf("%s", report)
+	t.FailNow()
+}
+
+// formatMessage formats the optional message, which is either a single value
+// or a format string followed by its arguments.
+func formatMessage(msgAndArgs ...interface{}) string {
+	switch len(msgAndArgs) {
+	case 0:
+		return ""
+	case 1:
+		if msg, ok := msgAndArgs[0].(string); ok {
+			return msg
+		}
+		return fmt.Sprintf("%+v", msgAndArgs[0])
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return fmt.Sprintf(format, msgAndArgs[1:]...)
+	}
+	return fmt.Sprintf("%+v", msgAndArgs)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasatest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// mockT records the failures of assertions.
+type mockT struct {
+	errors []string
+	failed bool
+}
+
+// Errorf implements TestingT.
+func (t *mockT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// FailNow implements TestingT.
+func (t *mockT) FailNow() {
+	t.failed = true
+}
+
+// TestEqualEvents
+func TestEqualEvents(t *testing.T) {
+	expected := rasa.Events{
+		&rasa.ActionExecuted{ActionName: "action_greet"},
+		&rasa.SlotSet{Key: "city", Value: "Paris"},
+	}
+
+	mock := new(mockT)
+	EqualEvents(mock, expected, rasa.Events{
+		rasa.ActionExecuted{ActionName: "action_greet"},
+		&rasa.SlotSet{Key: "city", Value: "Paris", Timestamp: rasa.Time{}},
+	})
+	require.False(t, mock.failed)
+
+	EqualEvents(mock, expected, rasa.Events{
+		&rasa.ActionExecuted{ActionName: "action_greet"},
+		&rasa.SlotSet{Key: "city", Value: "Rome"},
+	}, "handler %s", "action_greet")
+	require.True(t, mock.failed)
+	require.Equal(t, []string{
+		"handler action_greet\nevents differ:\n" +
+			"  - [1] slot city=\"Paris\"\n" +
+			"  + [1] slot city=\"Rome\"\n",
+	}, mock.errors)
+}
+
+// TestEqualTrackers
+func TestEqualTrackers(t *testing.T) {
+	expected := &rasa.Tracker{SenderID: "user", Slots: rasa.Slots{"city": "Paris"}}
+
+	mock := new(mockT)
+	EqualTrackers(mock, expected, &rasa.Tracker{SenderID: "user", Slots: rasa.Slots{"city": "Paris"}})
+	require.False(t, mock.failed)
+
+	EqualTrackers(mock, expected, &rasa.Tracker{SenderID: "user"})
+	require.True(t, mock.failed)
+	require.Equal(t, []string{"trackers differ:\nslots:\n  - city: \"Paris\"\n"}, mock.errors)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"mime"
+	"net/url"
+	"path"
+
+	"go.scarlet.dev/rasa"
+)
+
+// contentTypeHeroCard is the content type of hero card attachments.
+const contentTypeHeroCard = "application/vnd.microsoft.card.hero"
+
+// BotFramework implements Renderer for the Microsoft Bot Framework.
+//
+// Buttons are rendered as a hero card holding the text, and quick replies
+// as suggested actions. Images and attachments are attached by URL, and the
+// elements of carousels are rendered as hero cards using the carousel
+// layout. Custom payloads are sent as a separate payload.
+//
+// The payload is an activity of type message.
+type BotFramework struct{}
+
+// ensure interface
+var _ Renderer = BotFramework{}
+
+// Render implements Renderer.
+func (BotFramework) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	elems, err := msg.CarouselElements()
+	if err != nil {
+		return nil, err
+	}
+
+	activity := rasa.JSONMap{"type": "message"}
+	var attachments []interface{}
+	if len(msg.Buttons) > 0 {
+		attachments = append(attachments, botFrameworkHeroCard(rasa.JSONMap{
+			"text":    msg.Text,
+			"buttons": botFrameworkActions(msg.Buttons),
+		}))
+	} else if msg.Text != "" {
+		activity["text"] = msg.Text
+	}
+	if msg.Image != "" {
+		attachments = append(attachments, botFrameworkAttachment(msg.Image, "image/png"))
+	}
+	if url := msg.AttachmentURL(); url != "" {
+		attachments = append(attachments, botFrameworkAttachment(url, "application/octet-stream"))
+	}
+
+	for i := range elems {
+		card := rasa.JSONMap{"title": elems[i].Title}
+		if elems[i].Subtitle != "" {
+			card["subtitle"] = elems[i].Subtitle
+		}
+		if elems[i].ImageURL != "" {
+			card["images"] = []interface{}{rasa.JSONMap{"url": elems[i].ImageURL}}
+		}
+		if elems[i].DefaultURL != "" {
+			card["tap"] = rasa.JSONMap{"type": "openUrl", "value": elems[i].DefaultURL}
+		}
+		if len(elems[i].Buttons) > 0 {
+			card["buttons"] = botFrameworkActions(elems[i].Buttons)
+		}
+		attachments = append(attachments, botFrameworkHeroCard(card))
+	}
+	if len(elems) > 1 {
+		activity["attachmentLayout"] = "carousel"
+	}
+
+	if len(msg.QuickReplies) > 0 {
+		activity["suggestedActions"] = rasa.JSONMap{
+			"actions": botFrameworkActions(msg.QuickReplies),
+		}
+	}
+
+	var payloads []rasa.JSONMap
+	if len(attachments) > 0 {
+		activity["attachments"] = attachments
+	}
+	if len(activity) > 1 {
+		payloads = append(payloads, activity)
+	}
+	return appendCustom(payloads, msg), nil
+}
+
+// botFrameworkHeroCard returns a hero card attachment.
+func botFrameworkHeroCard(content rasa.JSONMap) rasa.JSONMap {
+	return rasa.JSONMap{
+		"contentType": contentTypeHeroCard,
+		"content":     content,
+	}
+}
+
+// botFrameworkAttachment returns an attachment with the URL. The content
+// type is derived from the extension of the URL, if known.
+func botFrameworkAttachment(rawurl, fallback string) rasa.JSONMap {
+	contentType := fallback
+	if u, err := url.Parse(rawurl); err == nil {
+		if typ := mime.TypeByExtension(path.Ext(u.Path)); typ != "" {
+			contentType = typ
+		}
+	}
+	return rasa.JSONMap{
+		"contentType": contentType,
+		"contentUrl":  rawurl,
+	}
+}
+
+// botFrameworkActions returns the card actions for the buttons.
+func botFrameworkActions(buttons []rasa.Button) []interface{} {
+	actions := make([]interface{}, len(buttons))
+	for i := range buttons {
+		if isURLButton(&buttons[i]) {
+			actions[i] = rasa.JSONMap{
+				"type":  "openUrl",
+				"title": buttons[i].Title,
+				"value": buttons[i].URL,
+			}
+		} else {
+			actions[i] = rasa.JSONMap{
+				"type":  "imBack",
+				"title": buttons[i].Title,
+				"value": buttons[i].Payload,
+			}
+		}
+	}
+	return actions
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package render converts channel-agnostic rasa.Message values into the
+// payloads of specific channels, such as Slack Block Kit messages, Telegram
+// reply markup, Facebook Messenger templates, and Bot Framework activities.
+//
+// Features a channel lacks degrade gracefully; for example, buttons are
+// rendered as a numbered list by the Text renderer. Use For to look up the
+// Renderer by the name of the channel, as found in the NLG request or in
+// UserUttered.InputChannel.
+package render
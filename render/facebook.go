@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"go.scarlet.dev/rasa"
+)
+
+// Limits of the Messenger Platform.
+const (
+	facebookMaxButtons      = 3
+	facebookMaxQuickReplies = 13
+	facebookMaxElements     = 10
+)
+
+// Facebook implements Renderer for Facebook Messenger.
+//
+// Messages with buttons are rendered as a button template. Messenger only
+// supports three buttons per template, so messages with more buttons are
+// rendered as quick replies instead, with the buttons opening a URL listed
+// in the text. The same applies to messages without text, as the button
+// template requires one. Quick replies are sent with the first payload, and
+// an error is returned if there is none. Images and attachments are sent as
+// separate attachments, and the elements of carousels as generic templates
+// of at most ten elements. Custom payloads are sent as a separate payload.
+//
+// Every payload is a message object of the Send API, to which the caller
+// adds the recipient.
+type Facebook struct{}
+
+// ensure interface
+var _ Renderer = Facebook{}
+
+// Render implements Renderer.
+func (Facebook) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	elems, err := msg.CarouselElements()
+	if err != nil {
+		return nil, err
+	}
+
+	text := msg.Text
+	quickReplies := msg.QuickReplies
+	var buttons []rasa.Button
+	if msg.Text != "" && len(msg.Buttons) <= facebookMaxButtons {
+		buttons = msg.Buttons
+	} else if len(msg.Buttons) > 0 {
+		quickReplies = make([]rasa.Button, 0, len(msg.Buttons)+len(msg.QuickReplies))
+		for i := range msg.Buttons {
+			if isURLButton(&msg.Buttons[i]) {
+				if text != "" {
+					text += "\n"
+				}
+				text += buttonText(&msg.Buttons[i])
+			} else {
+				quickReplies = append(quickReplies, msg.Buttons[i])
+			}
+		}
+		quickReplies = append(quickReplies, msg.QuickReplies...)
+	}
+
+	var payloads []rasa.JSONMap
+	switch {
+	case len(buttons) > 0:
+		payloads = append(payloads, facebookTemplate(rasa.JSONMap{
+			"template_type": "button",
+			"text":          text,
+			"buttons":       facebookButtons(buttons),
+		}))
+	case text != "":
+		payloads = append(payloads, rasa.JSONMap{"text": text})
+	}
+	if msg.Image != "" {
+		payloads = append(payloads, facebookAttachment("image", msg.Image))
+	}
+	if url := msg.AttachmentURL(); url != "" {
+		typ := "file"
+		if attachment, ok := msg.Attachment.(rasa.JSONMap); ok {
+			if t, ok := attachment["type"].(string); ok && t != "" {
+				typ = t
+			}
+		}
+		payloads = append(payloads, facebookAttachment(typ, url))
+	}
+
+	for len(elems) > 0 {
+		n := len(elems)
+		if n > facebookMaxElements {
+			n = facebookMaxElements
+		}
+
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = facebookElement(&elems[i])
+		}
+		payloads = append(payloads, facebookTemplate(rasa.JSONMap{
+			"template_type": "generic",
+			"elements":      list,
+		}))
+		elems = elems[n:]
+	}
+
+	if len(quickReplies) > 0 {
+		if len(payloads) == 0 {
+			return nil, errMissingText
+		}
+		if len(quickReplies) > facebookMaxQuickReplies {
+			quickReplies = quickReplies[:facebookMaxQuickReplies]
+		}
+		replies := make([]interface{}, len(quickReplies))
+		for i := range quickReplies {
+			replies[i] = rasa.JSONMap{
+				"content_type": "text",
+				"title":        quickReplies[i].Title,
+				"payload":      quickReplies[i].Payload,
+			}
+		}
+		payloads[0]["quick_replies"] = replies
+	}
+	return appendCustom(payloads, msg), nil
+}
+
+// facebookTemplate returns a template message.
+func facebookTemplate(payload rasa.JSONMap) rasa.JSONMap {
+	return facebookAttachment("template", payload)
+}
+
+// facebookAttachment returns an attachment message. Attachments other than
+// templates are sent by URL.
+func facebookAttachment(typ string, payload interface{}) rasa.JSONMap {
+	if url, ok := payload.(string); ok {
+		payload = rasa.JSONMap{"url": url}
+	}
+	return rasa.JSONMap{
+		"attachment": rasa.JSONMap{
+			"type":    typ,
+			"payload": payload,
+		},
+	}
+}
+
+// facebookButtons returns the buttons of a template, which require a type.
+func facebookButtons(buttons []rasa.Button) []interface{} {
+	if len(buttons) > facebookMaxButtons {
+		buttons = buttons[:facebookMaxButtons]
+	}
+
+	result := make([]interface{}, len(buttons))
+	for i := range buttons {
+		if isURLButton(&buttons[i]) {
+			result[i] = rasa.JSONMap{
+				"type":  rasa.ButtonTypeWebURL,
+				"title": buttons[i].Title,
+				"url":   buttons[i].URL,
+			}
+		} else {
+			result[i] = rasa.JSONMap{
+				"type":    rasa.ButtonTypePostback,
+				"title":   buttons[i].Title,
+				"payload": buttons[i].Payload,
+			}
+		}
+	}
+	return result
+}
+
+// facebookElement returns the element of a generic template.
+func facebookElement(e *rasa.Element) rasa.JSONMap {
+	m := rasa.JSONMap{"title": e.Title}
+	if e.Subtitle != "" {
+		m["subtitle"] = e.Subtitle
+	}
+	if e.ImageURL != "" {
+		m["image_url"] = e.ImageURL
+	}
+	if e.DefaultURL != "" {
+		m["default_action"] = rasa.JSONMap{
+			"type": rasa.ButtonTypeWebURL,
+			"url":  e.DefaultURL,
+		}
+	}
+	if len(e.Buttons) > 0 {
+		m["buttons"] = facebookButtons(e.Buttons)
+	}
+	return m
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	perrors "github.com/pkg/errors"
+	"go.scarlet.dev/rasa"
+)
+
+// Names of the channels for which renderers are registered by default.
+const (
+	ChannelSlack        = "slack"
+	ChannelTelegram     = "telegram"
+	ChannelFacebook     = "facebook"
+	ChannelBotFramework = "botframework"
+	ChannelRest         = "rest"
+	ChannelCallback     = "callback"
+	ChannelSocketIO     = "socketio"
+)
+
+// Renderer converts a channel-agnostic message into the payloads of a
+// channel.
+type Renderer interface {
+	// Render returns the payloads to send to the channel, in order. A
+	// single message can result in several payloads, for example if the
+	// channel sends images separately from text.
+	Render(msg *rasa.Message) ([]rasa.JSONMap, error)
+}
+
+// RendererFunc implements Renderer with a function.
+type RendererFunc func(msg *rasa.Message) ([]rasa.JSONMap, error)
+
+// ensure interface
+var _ Renderer = (RendererFunc)(nil)
+
+// Render implements Renderer.
+func (fn RendererFunc) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	return fn(msg)
+}
+
+// renderers holds the registered renderers by channel name.
+var renderers = struct {
+	sync.RWMutex
+	m map[string]Renderer
+}{
+	m: map[string]Renderer{
+		ChannelSlack:        Slack{},
+		ChannelTelegram:     Telegram{},
+		ChannelFacebook:     Facebook{},
+		ChannelBotFramework: BotFramework{},
+		ChannelRest:         Passthrough{},
+		ChannelCallback:     Passthrough{},
+		ChannelSocketIO:     Passthrough{},
+	},
+}
+
+// Register registers the Renderer for the channel with the provided name.
+//
+// Every channel can only be registered once, so the function will panic if
+// the channel is already registered, including the channels registered by
+// the SDK. Register should be called during initialization.
+func Register(channel string, r Renderer) {
+	renderers.Lock()
+	defer renderers.Unlock()
+
+	if _, exists := renderers.m[channel]; exists {
+		panic(fmt.Sprintf("renderer for channel [%s] already registered", channel))
+	}
+	renderers.m[channel] = r
+}
+
+// For returns the Renderer for the channel with the provided name, such as
+// nlg.Channel.Name or UserUttered.InputChannel. Channels without a
+// registered renderer use Text.
+func For(channel string) Renderer {
+	renderers.RLock()
+	defer renderers.RUnlock()
+
+	if r, ok := renderers.m[channel]; ok {
+		return r
+	}
+	return Text{}
+}
+
+// ForTracker returns the Renderer for the latest input channel of the
+// tracker.
+func ForTracker(tracker *rasa.Tracker) Renderer {
+	if tracker.LatestInputChannel != "" {
+		return For(tracker.LatestInputChannel)
+	}
+	if e, ok := tracker.LastEventFor(rasa.EventTypeUserUttered).(*rasa.UserUttered); ok {
+		return For(e.InputChannel)
+	}
+	return Text{}
+}
+
+// Message renders the message for the channel with the provided name.
+//
+// Messages restricted to another channel with Message.Channel result in no
+// payloads.
+func Message(channel string, msg *rasa.Message) ([]rasa.JSONMap, error) {
+	if msg.Channel != "" && msg.Channel != channel {
+		return nil, nil
+	}
+	return For(channel).Render(msg)
+}
+
+// Passthrough implements Renderer for channels which support rasa.Message
+// as is, such as the rest and callback channels.
+type Passthrough struct{}
+
+// ensure interface
+var _ Renderer = Passthrough{}
+
+// Render implements Renderer.
+func (Passthrough) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	ser, err := json.Marshal(msg)
+	if err != nil {
+		return nil, perrors.WithMessage(err, "could not marshal the message")
+	}
+
+	var payload rasa.JSONMap
+	if err := json.Unmarshal(ser, &payload); err != nil {
+		return nil, perrors.WithMessage(err, "could not unmarshal the message")
+	}
+	return []rasa.JSONMap{payload}, nil
+}
+
+// errMissingText is returned for messages with buttons or quick replies, but
+// no text or other payload to send them with.
+var errMissingText = perrors.New("buttons and quick replies require a text, image or attachment")
+
+// isURLButton returns whether the button opens a URL when clicked.
+func isURLButton(b *rasa.Button) bool {
+	return b.Type == rasa.ButtonTypeWebURL || (b.URL != "" && b.Payload == "")
+}
+
+// appendCustom appends the custom payload of the message, which is sent to
+// the channel as is.
+func appendCustom(payloads []rasa.JSONMap, msg *rasa.Message) []rasa.JSONMap {
+	if len(msg.Custom) > 0 {
+		payloads = append(payloads, msg.Custom)
+	}
+	return payloads
+}
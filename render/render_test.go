@@ -0,0 +1,290 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// testMessage returns a message using most features.
+func testMessage() *rasa.Message {
+	return rasa.NewMessage().
+		Text("Where to?").
+		Button("Paris", "/inform").
+		URLButton("Map", "https://example.com/map").
+		QuickReply("Later", "/later").
+		Build()
+}
+
+// testCarousel returns a message with a carousel.
+func testCarousel() *rasa.Message {
+	return rasa.NewMessage().
+		Carousel(rasa.Element{
+			Title:      "Paris",
+			Subtitle:   "France",
+			ImageURL:   "https://example.com/paris.png",
+			DefaultURL: "https://example.com/paris",
+			Buttons:    []rasa.Button{{Title: "Go", Payload: "/go"}},
+		}).
+		Build()
+}
+
+// TestRenderers
+func TestRenderers(t *testing.T) {
+	cases := []struct {
+		renderer Renderer
+		msg      *rasa.Message
+		expect   string
+	}{
+		{
+			renderer: Text{},
+			msg:      testMessage(),
+			expect:   `[{"text":"Where to?\n1. Paris\n2. Map: https://example.com/map\n3. Later"}]`,
+		},
+		{
+			renderer: Text{},
+			msg:      testCarousel(),
+			expect:   `[{"text":"Paris\nFrance\n1. Go"}]`,
+		},
+		{
+			renderer: Text{},
+			msg:      &rasa.Message{Custom: rasa.JSONMap{"a": 1}},
+			expect:   `null`,
+		},
+		{
+			renderer: Slack{},
+			msg:      testMessage(),
+			expect: `[{"text":"Where to?","blocks":[
+				{"type":"section","text":{"type":"mrkdwn","text":"Where to?"}},
+				{"type":"actions","elements":[
+					{"type":"button","text":{"type":"plain_text","text":"Paris"},"value":"/inform"},
+					{"type":"button","text":{"type":"plain_text","text":"Map"},"url":"https://example.com/map"},
+					{"type":"button","text":{"type":"plain_text","text":"Later"},"value":"/later"}
+				]}
+			]}]`,
+		},
+		{
+			renderer: Slack{},
+			msg:      testCarousel(),
+			expect: `[{"text":"","blocks":[
+				{"type":"section","text":{"type":"mrkdwn","text":"*Paris*\nFrance"},
+				 "accessory":{"type":"image","image_url":"https://example.com/paris.png","alt_text":"Paris"}},
+				{"type":"actions","elements":[
+					{"type":"button","text":{"type":"plain_text","text":"Go"},"value":"/go"}
+				]}
+			]}]`,
+		},
+		{
+			renderer: Slack{},
+			msg:      &rasa.Message{Image: "https://example.com/a.png", Custom: rasa.JSONMap{"a": 1}},
+			expect: `[
+				{"text":"","blocks":[{"type":"image","image_url":"https://example.com/a.png","alt_text":"image"}]},
+				{"a":1}
+			]`,
+		},
+		{
+			renderer: Telegram{},
+			msg:      testMessage(),
+			expect: `[{"method":"sendMessage","text":"Where to?","reply_markup":{"inline_keyboard":[
+				[{"text":"Paris","callback_data":"/inform"}],
+				[{"text":"Map","url":"https://example.com/map"}],
+				[{"text":"Later","callback_data":"/later"}]
+			]}}]`,
+		},
+		{
+			renderer: Telegram{},
+			msg:      rasa.NewMessage().Text("Ok?").QuickReply("Yes", "/affirm").Image("https://example.com/a.png").Build(),
+			expect: `[
+				{"method":"sendMessage","text":"Ok?","reply_markup":{
+					"keyboard":[[{"text":"Yes"}]],"one_time_keyboard":true,"resize_keyboard":true}},
+				{"method":"sendPhoto","photo":"https://example.com/a.png"}
+			]`,
+		},
+		{
+			renderer: Telegram{},
+			msg:      testCarousel(),
+			expect: `[{"method":"sendPhoto","photo":"https://example.com/paris.png","caption":"*Paris*\nFrance",
+				"parse_mode":"Markdown","reply_markup":{"inline_keyboard":[[{"text":"Go","callback_data":"/go"}]]}}]`,
+		},
+		{
+			// without text, the keyboard is sent with the photo
+			renderer: Telegram{},
+			msg:      rasa.NewMessage().QuickReply("Yes", "/affirm").Image("https://example.com/a.png").Build(),
+			expect: `[{"method":"sendPhoto","photo":"https://example.com/a.png","reply_markup":{
+				"keyboard":[[{"text":"Yes"}]],"one_time_keyboard":true,"resize_keyboard":true}}]`,
+		},
+		{
+			renderer: Telegram{},
+			msg:      rasa.NewMessage().Attachment("https://example.com/a.pdf").Button("Ok", "/ok").Build(),
+			expect: `[{"method":"sendDocument","document":"https://example.com/a.pdf","reply_markup":{
+				"inline_keyboard":[[{"text":"Ok","callback_data":"/ok"}]]}}]`,
+		},
+		{
+			renderer: Telegram{},
+			msg:      rasa.NewMessage().Carousel(rasa.Element{Title: "*a_b* [c]", Subtitle: "`d`"}).Build(),
+			expect:   `[{"method":"sendMessage","text":"*\\*a\\_b\\* \\[c]*\n\\` + "`" + `d\\` + "`" + `","parse_mode":"Markdown"}]`,
+		},
+		{
+			renderer: Slack{},
+			msg:      rasa.NewMessage().Carousel(rasa.Element{Title: "*a_b* <c>"}).Build(),
+			expect: `[{"text":"","blocks":[
+				{"type":"section","text":{"type":"mrkdwn","text":"**\u200ba_\u200bb*\u200b &lt;c&gt;*"}}
+			]}]`,
+		},
+		{
+			renderer: Facebook{},
+			msg:      testMessage(),
+			expect: `[{"attachment":{"type":"template","payload":{"template_type":"button","text":"Where to?","buttons":[
+					{"type":"postback","title":"Paris","payload":"/inform"},
+					{"type":"web_url","title":"Map","url":"https://example.com/map"}
+				]}},
+				"quick_replies":[{"content_type":"text","title":"Later","payload":"/later"}]
+			}]`,
+		},
+		{
+			// too many buttons degrade to quick replies
+			renderer: Facebook{},
+			msg: rasa.NewMessage().Text("Pick").
+				Button("1", "/a").Button("2", "/b").Button("3", "/c").
+				URLButton("Map", "https://example.com/map").Build(),
+			expect: `[{"text":"Pick\nMap: https://example.com/map","quick_replies":[
+				{"content_type":"text","title":"1","payload":"/a"},
+				{"content_type":"text","title":"2","payload":"/b"},
+				{"content_type":"text","title":"3","payload":"/c"}
+			]}]`,
+		},
+		{
+			// without text, buttons degrade to quick replies sent with the image
+			renderer: Facebook{},
+			msg:      rasa.NewMessage().Button("Ok", "/ok").QuickReply("Later", "/later").Image("https://example.com/a.png").Build(),
+			expect: `[{"attachment":{"type":"image","payload":{"url":"https://example.com/a.png"}},"quick_replies":[
+				{"content_type":"text","title":"Ok","payload":"/ok"},
+				{"content_type":"text","title":"Later","payload":"/later"}
+			]}]`,
+		},
+		{
+			renderer: Facebook{},
+			msg:      rasa.NewMessage().URLButton("Map", "https://example.com/map").QuickReply("Later", "/later").Build(),
+			expect: `[{"text":"Map: https://example.com/map","quick_replies":[
+				{"content_type":"text","title":"Later","payload":"/later"}
+			]}]`,
+		},
+		{
+			renderer: Facebook{},
+			msg: rasa.NewMessage().
+				Attachment(rasa.JSONMap{"type": "video", "payload": rasa.JSONMap{"src": "https://example.com/a.mp4"}}).
+				Build(),
+			expect: `[{"attachment":{"type":"video","payload":{"url":"https://example.com/a.mp4"}}}]`,
+		},
+		{
+			renderer: Facebook{},
+			msg:      testCarousel(),
+			expect: `[{"attachment":{"type":"template","payload":{"template_type":"generic","elements":[{
+				"title":"Paris","subtitle":"France","image_url":"https://example.com/paris.png",
+				"default_action":{"type":"web_url","url":"https://example.com/paris"},
+				"buttons":[{"type":"postback","title":"Go","payload":"/go"}]
+			}]}}}]`,
+		},
+		{
+			renderer: BotFramework{},
+			msg:      testMessage(),
+			expect: `[{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.hero","content":{
+					"text":"Where to?","buttons":[
+						{"type":"imBack","title":"Paris","value":"/inform"},
+						{"type":"openUrl","title":"Map","value":"https://example.com/map"}
+					]}}],
+				"suggestedActions":{"actions":[{"type":"imBack","title":"Later","value":"/later"}]}
+			}]`,
+		},
+		{
+			renderer: BotFramework{},
+			msg:      rasa.NewMessage().Text("Hi").Build(),
+			expect:   `[{"type":"message","text":"Hi"}]`,
+		},
+		{
+			renderer: BotFramework{},
+			msg:      testCarousel(),
+			expect: `[{"type":"message","attachments":[{"contentType":"application/vnd.microsoft.card.hero","content":{
+				"title":"Paris","subtitle":"France","images":[{"url":"https://example.com/paris.png"}],
+				"tap":{"type":"openUrl","value":"https://example.com/paris"},
+				"buttons":[{"type":"imBack","title":"Go","value":"/go"}]
+			}}]}]`,
+		},
+		{
+			renderer: Passthrough{},
+			msg:      rasa.NewMessage().Text("Hi").Button("Ok", "/ok").Build(),
+			expect:   `[{"text":"Hi","buttons":[{"title":"Ok","payload":"/ok"}]}]`,
+		},
+	}
+
+	for i := range cases {
+		payloads, err := cases[i].renderer.Render(cases[i].msg)
+		require.NoErrorf(t, err, "failed on %d", i)
+
+		ser, err := json.Marshal(payloads)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.JSONEqf(t, cases[i].expect, string(ser), "failed on %d", i)
+	}
+}
+
+// TestTelegramCallbackData
+func TestTelegramCallbackData(t *testing.T) {
+	msg := rasa.NewMessage().Button("Long", "/"+strings.Repeat("a", 64)).Build()
+	_, err := Telegram{}.Render(msg)
+	require.Error(t, err)
+}
+
+// TestMissingText
+func TestMissingText(t *testing.T) {
+	cases := []struct {
+		renderer Renderer
+		msg      *rasa.Message
+	}{
+		{renderer: Telegram{}, msg: rasa.NewMessage().Button("Ok", "/ok").Build()},
+		{renderer: Telegram{}, msg: rasa.NewMessage().QuickReply("Ok", "/ok").Build()},
+		{renderer: Facebook{}, msg: rasa.NewMessage().Button("Ok", "/ok").Build()},
+		{renderer: Facebook{}, msg: rasa.NewMessage().QuickReply("Ok", "/ok").Build()},
+	}
+
+	for i := range cases {
+		_, err := cases[i].renderer.Render(cases[i].msg)
+		require.Equalf(t, errMissingText, err, "failed on %d", i)
+	}
+}
+
+// TestFor
+func TestFor(t *testing.T) {
+	require.Equal(t, Slack{}, For(ChannelSlack))
+	require.Equal(t, Passthrough{}, For(ChannelRest))
+	require.Equal(t, Text{}, For("sms"))
+	require.Equal(t, Text{}, For(""))
+
+	tracker := &rasa.Tracker{Events: rasa.Events{&rasa.UserUttered{Text: "hi", InputChannel: "telegram"}}}
+	require.Equal(t, Telegram{}, ForTracker(tracker))
+	tracker.LatestInputChannel = ChannelFacebook
+	require.Equal(t, Facebook{}, ForTracker(tracker))
+	require.Equal(t, Text{}, ForTracker(&rasa.Tracker{}))
+
+	Register("test_channel", RendererFunc(func(msg *rasa.Message) ([]rasa.JSONMap, error) {
+		return []rasa.JSONMap{{"test": msg.Text}}, nil
+	}))
+	payloads, err := Message("test_channel", &rasa.Message{Text: "Hi"})
+	require.NoError(t, err)
+	require.Equal(t, []rasa.JSONMap{{"test": "Hi"}}, payloads)
+
+	require.Panics(t, func() { Register(ChannelSlack, Text{}) })
+
+	// messages restricted to another channel are not rendered
+	payloads, err = Message(ChannelSlack, &rasa.Message{Text: "Hi", Channel: ChannelTelegram})
+	require.NoError(t, err)
+	require.Nil(t, payloads)
+}
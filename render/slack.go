@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"strings"
+
+	"go.scarlet.dev/rasa"
+)
+
+// Slack implements Renderer for Slack, using Block Kit.
+//
+// The text is rendered as a section, images as image blocks, and buttons and
+// quick replies as buttons in an actions block. Every element of a carousel
+// is rendered as a section with its image as accessory, followed by its
+// buttons. Custom payloads are sent as a separate payload.
+//
+// The payload has the form {"text": "...", "blocks": [...]}, which can be
+// passed to chat.postMessage.
+type Slack struct{}
+
+// ensure interface
+var _ Renderer = Slack{}
+
+// Render implements Renderer.
+func (Slack) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	elems, err := msg.CarouselElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []interface{}
+	if msg.Text != "" {
+		blocks = append(blocks, slackSection(msg.Text))
+	}
+	if msg.Image != "" {
+		blocks = append(blocks, rasa.JSONMap{
+			"type":      "image",
+			"image_url": msg.Image,
+			"alt_text":  altText(msg.Text),
+		})
+	}
+	if url := msg.AttachmentURL(); url != "" {
+		blocks = append(blocks, slackSection("<"+url+">"))
+	}
+
+	buttons := make([]rasa.Button, 0, len(msg.Buttons)+len(msg.QuickReplies))
+	buttons = append(buttons, msg.Buttons...)
+	buttons = append(buttons, msg.QuickReplies...)
+	if len(buttons) > 0 {
+		blocks = append(blocks, slackActions(buttons))
+	}
+
+	for i := range elems {
+		section := slackSection(elementText(&elems[i], "*", "\n", slackEscaper))
+		if elems[i].ImageURL != "" {
+			section["accessory"] = rasa.JSONMap{
+				"type":      "image",
+				"image_url": elems[i].ImageURL,
+				"alt_text":  altText(elems[i].Title),
+			}
+		}
+		blocks = append(blocks, section)
+		if len(elems[i].Buttons) > 0 {
+			blocks = append(blocks, slackActions(elems[i].Buttons))
+		}
+	}
+
+	var payloads []rasa.JSONMap
+	if len(blocks) > 0 {
+		payloads = append(payloads, rasa.JSONMap{
+			"text":   msg.Text,
+			"blocks": blocks,
+		})
+	}
+	return appendCustom(payloads, msg), nil
+}
+
+// slackEscaper escapes text for mrkdwn. Slack has no escape sequence for
+// the formatting characters, so they are followed by a zero-width space,
+// which keeps them from pairing with the emphasis of the title.
+var slackEscaper = strings.NewReplacer(
+	`&`, `&amp;`, `<`, `&lt;`, `>`, `&gt;`,
+	`*`, "*\u200b", `_`, "_\u200b", `~`, "~\u200b", "`", "`\u200b",
+)
+
+// slackSection returns a section block with the markdown text.
+func slackSection(text string) rasa.JSONMap {
+	return rasa.JSONMap{
+		"type": "section",
+		"text": rasa.JSONMap{"type": "mrkdwn", "text": text},
+	}
+}
+
+// slackActions returns an actions block with the buttons.
+func slackActions(buttons []rasa.Button) rasa.JSONMap {
+	elements := make([]interface{}, len(buttons))
+	for i := range buttons {
+		button := rasa.JSONMap{
+			"type": "button",
+			"text": rasa.JSONMap{"type": "plain_text", "text": buttons[i].Title},
+		}
+		if isURLButton(&buttons[i]) {
+			button["url"] = buttons[i].URL
+		} else {
+			button["value"] = buttons[i].Payload
+		}
+		elements[i] = button
+	}
+	return rasa.JSONMap{"type": "actions", "elements": elements}
+}
+
+// altText returns the alternative text of an image, which Slack requires.
+func altText(text string) string {
+	if text == "" {
+		return "image"
+	}
+	return text
+}
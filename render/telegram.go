@@ -0,0 +1,146 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"strings"
+
+	perrors "github.com/pkg/errors"
+	"go.scarlet.dev/rasa"
+)
+
+// telegramMaxCallbackData is the maximum length in bytes of the callback data
+// of inline keyboard buttons.
+const telegramMaxCallbackData = 64
+
+// Telegram implements Renderer for the Telegram Bot API.
+//
+// Buttons are rendered as an inline keyboard. Quick replies are rendered as
+// a one-time reply keyboard if the message has no buttons, and as part of
+// the inline keyboard otherwise. Without text, the keyboard is sent with the
+// image or attachment, and an error is returned if there is neither. Images
+// and attachments are sent with sendPhoto and sendDocument. Telegram has no
+// carousels, so every element is sent as a separate photo or text message
+// with its buttons. Custom payloads are sent as a separate payload.
+//
+// Every payload holds the Bot API method to call in the "method" field, as
+// supported by the responses to webhook updates.
+type Telegram struct{}
+
+// ensure interface
+var _ Renderer = Telegram{}
+
+// Render implements Renderer.
+func (Telegram) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	elems, err := msg.CarouselElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var markup rasa.JSONMap
+	switch {
+	case len(msg.Buttons) > 0:
+		buttons := make([]rasa.Button, 0, len(msg.Buttons)+len(msg.QuickReplies))
+		buttons = append(buttons, msg.Buttons...)
+		buttons = append(buttons, msg.QuickReplies...)
+		if markup, err = telegramInlineKeyboard(buttons); err != nil {
+			return nil, err
+		}
+	case len(msg.QuickReplies) > 0:
+		keyboard := make([]interface{}, len(msg.QuickReplies))
+		for i := range msg.QuickReplies {
+			keyboard[i] = []interface{}{rasa.JSONMap{"text": msg.QuickReplies[i].Title}}
+		}
+		markup = rasa.JSONMap{
+			"keyboard":          keyboard,
+			"one_time_keyboard": true,
+			"resize_keyboard":   true,
+		}
+	}
+
+	var payloads []rasa.JSONMap
+	if msg.Text != "" {
+		payloads = append(payloads, telegramMessage(msg.Text, markup))
+	}
+	if msg.Image != "" {
+		payloads = append(payloads, rasa.JSONMap{"method": "sendPhoto", "photo": msg.Image})
+	}
+	if url := msg.AttachmentURL(); url != "" {
+		payloads = append(payloads, rasa.JSONMap{"method": "sendDocument", "document": url})
+	}
+	if msg.Text == "" && markup != nil {
+		// Telegram rejects empty messages, so the keyboard is sent with the
+		// photo or document
+		if len(payloads) == 0 {
+			return nil, errMissingText
+		}
+		payloads[0]["reply_markup"] = markup
+	}
+
+	for i := range elems {
+		var markup rasa.JSONMap
+		if len(elems[i].Buttons) > 0 {
+			if markup, err = telegramInlineKeyboard(elems[i].Buttons); err != nil {
+				return nil, perrors.WithMessagef(err, "invalid element %d", i)
+			}
+		}
+
+		text := elementText(&elems[i], "*", "\n", telegramEscaper)
+		var payload rasa.JSONMap
+		if elems[i].ImageURL != "" {
+			payload = rasa.JSONMap{
+				"method":  "sendPhoto",
+				"photo":   elems[i].ImageURL,
+				"caption": text,
+			}
+			if markup != nil {
+				payload["reply_markup"] = markup
+			}
+		} else {
+			payload = telegramMessage(text, markup)
+		}
+		payload["parse_mode"] = "Markdown"
+		payloads = append(payloads, payload)
+	}
+	return appendCustom(payloads, msg), nil
+}
+
+// telegramEscaper escapes text for the Markdown parse mode.
+var telegramEscaper = strings.NewReplacer(`_`, `\_`, `*`, `\*`, "`", "\\`", `[`, `\[`)
+
+// telegramMessage returns a sendMessage payload.
+func telegramMessage(text string, markup rasa.JSONMap) rasa.JSONMap {
+	payload := rasa.JSONMap{"method": "sendMessage", "text": text}
+	if markup != nil {
+		payload["reply_markup"] = markup
+	}
+	return payload
+}
+
+// telegramInlineKeyboard returns an inline keyboard with a row for every
+// button. An error is returned if the payload of a button exceeds the limit
+// of callback data.
+func telegramInlineKeyboard(buttons []rasa.Button) (rasa.JSONMap, error) {
+	rows := make([]interface{}, len(buttons))
+	for i := range buttons {
+		button := rasa.JSONMap{"text": buttons[i].Title}
+		if isURLButton(&buttons[i]) {
+			button["url"] = buttons[i].URL
+		} else {
+			if len(buttons[i].Payload) > telegramMaxCallbackData {
+				return nil, perrors.Errorf(
+					"payload of button [%s] exceeds %d bytes",
+					buttons[i].Title,
+					telegramMaxCallbackData,
+				)
+			}
+			button["callback_data"] = buttons[i].Payload
+		}
+		rows[i] = []interface{}{button}
+	}
+	return rasa.JSONMap{"inline_keyboard": rows}, nil
+}
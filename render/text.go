@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"go.scarlet.dev/rasa"
+)
+
+// Text implements Renderer for channels which only support plain text.
+//
+// Buttons and quick replies are rendered as a numbered list, so that users
+// can answer with the number or title of an option, and images, attachments
+// and the elements of carousels are rendered as lines of text. Custom
+// payloads are dropped.
+//
+// The payload has the form {"text": "..."}.
+type Text struct{}
+
+// ensure interface
+var _ Renderer = Text{}
+
+// Render implements Renderer.
+func (Text) Render(msg *rasa.Message) ([]rasa.JSONMap, error) {
+	elems, err := msg.CarouselElements()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if msg.Text != "" {
+		lines = append(lines, msg.Text)
+	}
+	if msg.Image != "" {
+		lines = append(lines, msg.Image)
+	}
+	if url := msg.AttachmentURL(); url != "" {
+		lines = append(lines, url)
+	}
+
+	n := 0
+	lines, n = appendOptions(lines, n, msg.Buttons)
+	lines, n = appendOptions(lines, n, msg.QuickReplies)
+	for i := range elems {
+		lines = append(lines, elementText(&elems[i], "", "\n", nil))
+		lines, n = appendOptions(lines, n, elems[i].Buttons)
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	return []rasa.JSONMap{{"text": strings.Join(lines, "\n")}}, nil
+}
+
+// appendOptions appends the buttons as numbered lines, starting at n+1, and
+// returns the number of the last option.
+func appendOptions(lines []string, n int, buttons []rasa.Button) ([]string, int) {
+	for i := range buttons {
+		n++
+		lines = append(lines, strconv.Itoa(n)+". "+buttonText(&buttons[i]))
+	}
+	return lines, n
+}
+
+// buttonText returns the text of a button, including its URL for buttons
+// which open one.
+func buttonText(b *rasa.Button) string {
+	if isURLButton(b) {
+		return b.Title + ": " + b.URL
+	}
+	return b.Title
+}
+
+// elementText returns the title and subtitle of the element, with the title
+// wrapped in the markup for emphasis. The title and subtitle are escaped
+// with the escaper, if any.
+func elementText(e *rasa.Element, emphasis, sep string, escaper *strings.Replacer) string {
+	title, subtitle := e.Title, e.Subtitle
+	if escaper != nil {
+		title, subtitle = escaper.Replace(title), escaper.Replace(subtitle)
+	}
+
+	text := emphasis + title + emphasis
+	if subtitle != "" {
+		text += sep + subtitle
+	}
+	return text
+}
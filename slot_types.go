@@ -0,0 +1,287 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	perrors "github.com/pkg/errors"
+)
+
+// ErrSlotNotDefined is the cause of a SlotError returned for slots which are
+// not defined in the domain.
+var ErrSlotNotDefined = perrors.New("slot is not defined in the domain")
+
+// SlotType implements the coercion and validation of the values of a slot
+// type.
+type SlotType interface {
+	// Coerce converts the value to the representation used by the slot type,
+	// or returns an error if the value is not valid for the slot. Coerce is
+	// never called with a nil value, which is valid for all slots.
+	Coerce(slot *SlotDescription, value interface{}) (interface{}, error)
+}
+
+// SlotTypeFunc implements SlotType with a function.
+type SlotTypeFunc func(slot *SlotDescription, value interface{}) (interface{}, error)
+
+// ensure interface
+var _ SlotType = (SlotTypeFunc)(nil)
+
+// Coerce implements SlotType.
+func (fn SlotTypeFunc) Coerce(slot *SlotDescription, value interface{}) (interface{}, error) {
+	return fn(slot, value)
+}
+
+// slotTypes holds the registered slot types.
+var slotTypes = struct {
+	sync.RWMutex
+	m map[string]SlotType
+}{
+	m: map[string]SlotType{
+		"text":         SlotTypeFunc(coerceTextSlot),
+		"bool":         SlotTypeFunc(coerceBoolSlot),
+		"float":        SlotTypeFunc(coerceFloatSlot),
+		"categorical":  SlotTypeFunc(coerceCategoricalSlot),
+		"list":         SlotTypeFunc(coerceListSlot),
+		"any":          SlotTypeFunc(coerceAnySlot),
+		"unfeaturized": SlotTypeFunc(coerceAnySlot),
+	},
+}
+
+// RegisterSlotType registers the SlotType for slots of the provided type.
+//
+// Custom slots are identified by the module path of their class, such as
+// "addons.slots.ColorSlot". Values of custom slots which are not registered
+// are not validated.
+//
+// Every slot type can only be registered once, so the function will panic if
+// the type is already registered, including the types provided by the SDK.
+// RegisterSlotType should be called during initialization.
+func RegisterSlotType(name string, typ SlotType) {
+	slotTypes.Lock()
+	defer slotTypes.Unlock()
+
+	if _, exists := slotTypes.m[name]; exists {
+		panic(fmt.Sprintf("slot type [%s] already registered", name))
+	}
+	slotTypes.m[name] = typ
+}
+
+// lookupSlotType returns the SlotType registered for the slot, or nil.
+func lookupSlotType(slot *SlotDescription) SlotType {
+	slotTypes.RLock()
+	defer slotTypes.RUnlock()
+	return slotTypes.m[slot.Kind()]
+}
+
+// Coerce converts the value to the representation used by the slot's type,
+// or returns an error if the value is not valid for the slot. Nil values are
+// always valid, as they reset the slot.
+func (s *SlotDescription) Coerce(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	typ := lookupSlotType(s)
+	if typ == nil {
+		return value, nil
+	}
+	return typ.Coerce(s, value)
+}
+
+// defaultSlots holds the names of Rasa's default slots.
+var defaultSlots = map[string]bool{
+	SlotRequested:                   true,
+	SlotSessionStartedMetadata:      true,
+	SlotKnowledgeBaseListedObjects:  true,
+	SlotKnowledgeBaseLastObject:     true,
+	SlotKnowledgeBaseLastObjectType: true,
+}
+
+// IsDefaultSlot returns whether the slot is one of Rasa's default slots, such
+// as "requested_slot". Default slots are added to every domain by Rasa, so
+// they are usually not declared in domain.yml.
+func IsDefaultSlot(name string) bool {
+	return defaultSlots[name]
+}
+
+// CoerceSlot converts the value for the slot with the provided name. A
+// *SlotError is returned if the slot is not defined, or if the value is not
+// valid for the slot.
+//
+// Rasa's default slots accept any value, unless they are declared in the
+// domain.
+func (d *Domain) CoerceSlot(name string, value interface{}) (interface{}, error) {
+	slot, ok := d.Slots[name]
+	if !ok && IsDefaultSlot(name) {
+		return value, nil
+	}
+	if !ok {
+		return nil, &SlotError{Slot: name, Value: value, Target: "slot", Cause: ErrSlotNotDefined}
+	}
+
+	coerced, err := slot.Coerce(value)
+	if err != nil {
+		return nil, &SlotError{Slot: name, Value: value, Target: slot.Kind() + " slot", Cause: err}
+	}
+	return coerced, nil
+}
+
+// ValidateSlotSet returns an error if the value of the SlotSet event is not
+// valid for the slot.
+func (d *Domain) ValidateSlotSet(e *SlotSet) error {
+	_, err := d.CoerceSlot(e.Key, e.Value)
+	return err
+}
+
+// CoerceEvents returns a copy of the events, in which the values of all
+// SlotSet events are converted according to the domain. Events other than
+// SlotSet are kept as is.
+//
+// An error is returned for the first SlotSet event with an invalid value.
+func (d *Domain) CoerceEvents(events Events) (Events, error) {
+	result := make(Events, len(events))
+	for i := range events {
+		result[i] = events[i]
+
+		e, ok := eventPointer(events[i]).(*SlotSet)
+		if !ok {
+			continue
+		}
+
+		value, err := d.CoerceSlot(e.Key, e.Value)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "invalid event %d", i)
+		}
+		if !reflect.DeepEqual(value, e.Value) {
+			coerced := *e
+			coerced.Value = value
+			result[i] = &coerced
+		}
+	}
+	return result, nil
+}
+
+// coerceTextSlot implements SlotType for text slots.
+func coerceTextSlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	return coerceString(value)
+}
+
+// coerceBoolSlot implements SlotType for bool slots.
+func coerceBoolSlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	return coerceBool(value)
+}
+
+// coerceFloatSlot implements SlotType for float slots. Values outside the
+// range of the slot are accepted unchanged, as Rasa stores them and only
+// caps them to the range when featurizing the slot.
+func coerceFloatSlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	return coerceFloat(value)
+}
+
+// categoricalOther is the value of categorical slots which accepts any value.
+const categoricalOther = "__other__"
+
+// coerceCategoricalSlot implements SlotType for categorical slots. Values are
+// formatted as by Python's str() and matched case-insensitively, as Rasa
+// does, and converted to the value listed in the domain.
+func coerceCategoricalSlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	var str string
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, errUnsupportedType(value)
+	default:
+		str = pythonString(value)
+	}
+
+	for _, allowed := range slot.Values {
+		if strings.EqualFold(allowed, str) {
+			return allowed, nil
+		}
+	}
+	if sliceContains(slot.Values, categoricalOther) {
+		return value, nil
+	}
+	return nil, perrors.Errorf("[%s] is not one of %v", str, slot.Values)
+}
+
+// coerceListSlot implements SlotType for list slots.
+func coerceListSlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	if _, ok := value.([]interface{}); ok {
+		return value, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, errUnsupportedType(value)
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// coerceAnySlot implements SlotType for slots accepting any value.
+func coerceAnySlot(slot *SlotDescription, value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+// pythonString formats the value as Python's str() formats the equivalent
+// Python value, such as "1.0" for the float 1 and "True" for true.
+func pythonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float64:
+		return pythonFloat(v)
+	case float32:
+		return pythonFloat(float64(v))
+	case json.Number:
+		// Python decodes numbers with a fraction or exponent as floats
+		if strings.ContainsAny(v.String(), ".eE") {
+			if f, err := v.Float64(); err == nil {
+				return pythonFloat(f)
+			}
+		}
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// pythonFloat formats the float as Python's str() does, which is the shortest
+// representation with at least one decimal, or in exponent notation for very
+// small and large values.
+func pythonFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e16) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(str, ".") {
+		str += ".0"
+	}
+	return str
+}
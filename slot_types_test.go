@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	RegisterSlotType("addons.slots.ColorSlot", SlotTypeFunc(func(slot *SlotDescription, value interface{}) (interface{}, error) {
+		color, err := coerceString(value)
+		if err != nil || !strings.HasPrefix(color, "#") {
+			return nil, errors.New("expected a hex color")
+		}
+		return strings.ToLower(color), nil
+	}))
+}
+
+// TestSlotTypes
+func TestSlotTypes(t *testing.T) {
+	var domain Domain
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"slots": {
+			"city": {"type": "text"},
+			"confirmed": {"type": "rasa.shared.core.slots.BooleanSlot"},
+			"travellers": {"type": "float", "min_value": 1, "max_value": 8},
+			"price": {"type": "float"},
+			"class": {"type": "categorical", "values": ["economy", "business"]},
+			"mood": {"type": "categorical", "values": ["happy", "__other__"]},
+			"stars": {"type": "categorical", "values": ["1.0", "2.5", "3", "1e-05", "true"]},
+			"cuisines": {"type": "list"},
+			"data": {"type": "any"},
+			"color": {"type": "addons.slots.ColorSlot"},
+			"unknown": {"type": "addons.slots.UnknownSlot"}
+		}
+	}`), &domain))
+
+	cases := []struct {
+		slot   string
+		value  interface{}
+		expect interface{}
+		err    bool
+	}{
+		{slot: "city", value: "Paris", expect: "Paris"},
+		{slot: "city", value: 12.0, err: true},
+		{slot: "city", value: nil, expect: nil},
+		{slot: "confirmed", value: true, expect: true},
+		{slot: "confirmed", value: "false", expect: false},
+		{slot: "confirmed", value: "maybe", err: true},
+		{slot: "confirmed", value: " TRUE ", expect: true},
+		{slot: "confirmed", value: 1, expect: true},
+		{slot: "confirmed", value: 0.0, expect: false},
+		{slot: "confirmed", value: json.Number("1"), expect: true},
+		{slot: "confirmed", value: "1", expect: true},
+		{slot: "confirmed", value: "0", expect: false},
+		{slot: "confirmed", value: "1.0", err: true},
+		{slot: "travellers", value: 2, expect: 2.0},
+		{slot: "travellers", value: "3", expect: 3.0},
+		{slot: "travellers", value: 0.0, expect: 0.0},
+		{slot: "travellers", value: 9.0, expect: 9.0},
+		{slot: "travellers", value: "many", err: true},
+		{slot: "price", value: 1e6, expect: 1e6},
+		{slot: "class", value: "Business", expect: "business"},
+		{slot: "class", value: "first", err: true},
+		{slot: "class", value: []interface{}{"economy"}, err: true},
+		{slot: "mood", value: "sad", expect: "sad"},
+		{slot: "stars", value: 1.0, expect: "1.0"},
+		{slot: "stars", value: 2.5, expect: "2.5"},
+		{slot: "stars", value: 3, expect: "3"},
+		{slot: "stars", value: 3.0, err: true},
+		{slot: "stars", value: json.Number("3"), expect: "3"},
+		{slot: "stars", value: json.Number("1.0"), expect: "1.0"},
+		{slot: "stars", value: 1e-5, expect: "1e-05"},
+		{slot: "stars", value: true, expect: "true"},
+		{slot: "cuisines", value: []interface{}{"greek"}, expect: []interface{}{"greek"}},
+		{slot: "cuisines", value: []string{"greek"}, expect: []interface{}{"greek"}},
+		{slot: "cuisines", value: "greek", err: true},
+		{slot: "data", value: JSONMap{"a": 1}, expect: JSONMap{"a": 1}},
+		{slot: "color", value: "#FF0000", expect: "#ff0000"},
+		{slot: "color", value: "red", err: true},
+		{slot: "unknown", value: 12, expect: 12},
+		{slot: "missing", value: "value", err: true},
+		{slot: "requested_slot", value: "city", expect: "city"},
+		{slot: "knowledge_base_listed_objects", value: []interface{}{1.0}, expect: []interface{}{1.0}},
+		{slot: "session_started_metadata", value: JSONMap{"a": 1}, expect: JSONMap{"a": 1}},
+	}
+
+	for i := range cases {
+		entry := cases[i]
+		value, err := domain.CoerceSlot(entry.slot, entry.value)
+		if entry.err {
+			require.Errorf(t, err, "failed on %d", i)
+			var serr *SlotError
+			require.Truef(t, errors.As(err, &serr), "failed on %d", i)
+			require.Equalf(t, entry.slot, serr.Slot, "failed on %d", i)
+			continue
+		}
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.Equalf(t, entry.expect, value, "failed on %d", i)
+	}
+
+	t.Run("undefined", func(t *testing.T) {
+		err := domain.ValidateSlotSet(&SlotSet{Key: "missing", Value: "value"})
+		require.True(t, errors.Is(err, ErrSlotNotDefined))
+		require.Equal(t, "slot [missing] is not defined in the domain", err.Error())
+	})
+
+	t.Run("CoerceEvents", func(t *testing.T) {
+		original := &SlotSet{Key: "class", Value: "ECONOMY"}
+		events, err := domain.CoerceEvents(Events{
+			&ActionExecuted{ActionName: "action_test"},
+			original,
+			SlotSet{Key: "city", Value: "Paris"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, Events{
+			&ActionExecuted{ActionName: "action_test"},
+			&SlotSet{Key: "class", Value: "economy"},
+			SlotSet{Key: "city", Value: "Paris"},
+		}, events)
+		require.Equal(t, "ECONOMY", original.Value)
+
+		_, err = domain.CoerceEvents(Events{&SlotSet{Key: "travellers", Value: "many"}})
+		require.Error(t, err)
+	})
+
+	t.Run("RegisterSlotType", func(t *testing.T) {
+		require.Panics(t, func() {
+			RegisterSlotType("text", SlotTypeFunc(coerceAnySlot))
+		})
+	})
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// StoryFormatVersion is the version of the training data format written by
+// the SDK.
+const StoryFormatVersion = "3.1"
+
+// SlotValueFilled is the value of slots listed without a value in the
+// slot_was_set steps of stories, the same as in Rasa.
+const SlotValueFilled = "filled"
+
+// StoryFile holds the stories and rules of a Rasa YAML training data file,
+// such as `data/stories.yml` or `tests/test_stories.yml`.
+type StoryFile struct {
+	// Version holds the version of the training data format.
+	Version string `yaml:"version,omitempty"`
+
+	// Stories holds the stories of the file.
+	Stories []Story `yaml:"stories,omitempty"`
+
+	// Rules holds the rules of the file.
+	Rules []Rule `yaml:"rules,omitempty"`
+}
+
+// ensure interface
+var _ yaml.Unmarshaler = (*StoryFile)(nil)
+
+// UnmarshalYAML implements yaml.Unmarshaler. Other training data, such as
+// NLU examples, is ignored.
+func (f *StoryFile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		Version interface{} `yaml:"version"`
+		Stories []Story     `yaml:"stories"`
+		Rules   []Rule      `yaml:"rules"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*f = StoryFile{Stories: raw.Stories, Rules: raw.Rules}
+	if raw.Version != nil {
+		// an unquoted version is parsed as a number
+		f.Version = fmt.Sprint(raw.Version)
+	}
+	return nil
+}
+
+// Marshal returns the YAML representation of the file. The version defaults
+// to StoryFormatVersion.
+func (f *StoryFile) Marshal() ([]byte, error) {
+	file := *f
+	if file.Version == "" {
+		file.Version = StoryFormatVersion
+	}
+	return yaml.Marshal(&file)
+}
+
+// Story holds a story of the training data.
+type Story struct {
+	// Name holds the name of the story.
+	Name string
+
+	// Steps holds the steps of the story.
+	Steps []StoryStep
+}
+
+// ensure interface
+var _ yaml.Marshaler = Story{}
+var _ yaml.Unmarshaler = (*Story)(nil)
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Story) MarshalYAML() (interface{}, error) {
+	return yaml.MapSlice{
+		{Key: "story", Value: s.Name},
+		{Key: "steps", Value: s.Steps},
+	}, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Story) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		Story string      `yaml:"story"`
+		Steps []StoryStep `yaml:"steps"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*s = Story{Name: raw.Story, Steps: raw.Steps}
+	return nil
+}
+
+// Rule holds a rule of the training data.
+type Rule struct {
+	// Name holds the name of the rule.
+	Name string
+
+	// Condition holds the steps which must precede the rule, which are
+	// limited to slots and active loops.
+	Condition []StoryStep
+
+	// ConversationStart indicates that the rule only applies at the start
+	// of a conversation.
+	ConversationStart bool
+
+	// WaitForUserInput indicates whether the rule ends by waiting for the
+	// next user message. Nil means true.
+	WaitForUserInput *bool
+
+	// Steps holds the steps of the rule.
+	Steps []StoryStep
+}
+
+// ensure interface
+var _ yaml.Marshaler = Rule{}
+var _ yaml.Unmarshaler = (*Rule)(nil)
+
+// MarshalYAML implements yaml.Marshaler.
+func (r Rule) MarshalYAML() (interface{}, error) {
+	m := yaml.MapSlice{{Key: "rule", Value: r.Name}}
+	if len(r.Condition) > 0 {
+		m = append(m, yaml.MapItem{Key: "condition", Value: r.Condition})
+	}
+	if r.ConversationStart {
+		m = append(m, yaml.MapItem{Key: "conversation_start", Value: true})
+	}
+	m = append(m, yaml.MapItem{Key: "steps", Value: r.Steps})
+	if r.WaitForUserInput != nil {
+		m = append(m, yaml.MapItem{Key: "wait_for_user_input", Value: *r.WaitForUserInput})
+	}
+	return m, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw struct {
+		Rule              string      `yaml:"rule"`
+		Condition         []StoryStep `yaml:"condition"`
+		ConversationStart bool        `yaml:"conversation_start"`
+		WaitForUserInput  *bool       `yaml:"wait_for_user_input"`
+		Steps             []StoryStep `yaml:"steps"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*r = Rule{
+		Name:              raw.Rule,
+		Condition:         raw.Condition,
+		ConversationStart: raw.ConversationStart,
+		WaitForUserInput:  raw.WaitForUserInput,
+		Steps:             raw.Steps,
+	}
+	return nil
+}
+
+// StoryStep holds a step of a story. Every step holds exactly one of the
+// user message, action, bot message, slots, active loop, checkpoint, or
+// alternative steps.
+type StoryStep struct {
+	// User holds the text of the user message, in which entities are
+	// annotated with Rasa's markdown syntax. It is only set for test
+	// stories and end-to-end stories.
+	User string
+
+	// Intent holds the intent of the user message.
+	Intent string
+
+	// Entities holds the entities of the user message.
+	Entities []StoryEntity
+
+	// Action holds the name of the executed action.
+	Action string
+
+	// Bot holds the text of an end-to-end bot message.
+	Bot string
+
+	// SlotWasSet holds the slots which were set.
+	SlotWasSet []StorySlot
+
+	// ActiveLoop holds the name of the loop which was activated. A pointer
+	// to an empty string represents a deactivated loop.
+	ActiveLoop *string
+
+	// Checkpoint holds the name of a checkpoint.
+	Checkpoint string
+
+	// Or holds alternative steps, one of which must match.
+	Or []StoryStep
+}
+
+// ensure interface
+var _ yaml.Marshaler = StoryStep{}
+var _ yaml.Unmarshaler = (*StoryStep)(nil)
+
+// storyStepKeys holds the keys of story steps.
+var storyStepKeys = []string{
+	"user", "intent", "entities", "action", "bot", "slot_was_set",
+	"active_loop", "checkpoint", "or", "metadata",
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s StoryStep) MarshalYAML() (interface{}, error) {
+	var m yaml.MapSlice
+	if s.User != "" {
+		// a trailing newline writes the text as a literal block, as Rasa
+		// does, which strips it when reading the text
+		m = append(m, yaml.MapItem{Key: "user", Value: s.User + "\n"})
+	}
+	if s.Intent != "" {
+		m = append(m, yaml.MapItem{Key: "intent", Value: s.Intent})
+	}
+	if len(s.Entities) > 0 {
+		m = append(m, yaml.MapItem{Key: "entities", Value: s.Entities})
+	}
+	if s.Action != "" {
+		m = append(m, yaml.MapItem{Key: "action", Value: s.Action})
+	}
+	if s.Bot != "" {
+		m = append(m, yaml.MapItem{Key: "bot", Value: s.Bot})
+	}
+	if len(s.SlotWasSet) > 0 {
+		m = append(m, yaml.MapItem{Key: "slot_was_set", Value: s.SlotWasSet})
+	}
+	if s.ActiveLoop != nil {
+		var loop interface{}
+		if *s.ActiveLoop != "" {
+			loop = *s.ActiveLoop
+		}
+		m = append(m, yaml.MapItem{Key: "active_loop", Value: loop})
+	}
+	if s.Checkpoint != "" {
+		m = append(m, yaml.MapItem{Key: "checkpoint", Value: s.Checkpoint})
+	}
+	if len(s.Or) > 0 {
+		m = append(m, yaml.MapItem{Key: "or", Value: s.Or})
+	}
+	return m, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *StoryStep) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var keys yaml.MapSlice
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	activeLoop := false
+	for i := range keys {
+		key := fmt.Sprint(keys[i].Key)
+		if !sliceContains(storyStepKeys, key) {
+			return perrors.Errorf("invalid story step with key [%s]", key)
+		}
+		activeLoop = activeLoop || key == "active_loop"
+	}
+
+	var raw struct {
+		User       string          `yaml:"user"`
+		Intent     string          `yaml:"intent"`
+		Entities   []StoryEntity   `yaml:"entities"`
+		Action     string          `yaml:"action"`
+		Bot        string          `yaml:"bot"`
+		SlotWasSet []storySlotItem `yaml:"slot_was_set"`
+		ActiveLoop string          `yaml:"active_loop"`
+		Checkpoint string          `yaml:"checkpoint"`
+		Or         []StoryStep     `yaml:"or"`
+	}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*s = StoryStep{
+		User:       strings.TrimSpace(raw.User),
+		Intent:     raw.Intent,
+		Entities:   raw.Entities,
+		Action:     raw.Action,
+		Bot:        strings.TrimSpace(raw.Bot),
+		Checkpoint: raw.Checkpoint,
+		Or:         raw.Or,
+	}
+	for i := range raw.SlotWasSet {
+		s.SlotWasSet = append(s.SlotWasSet, raw.SlotWasSet[i]...)
+	}
+	if activeLoop {
+		s.ActiveLoop = &raw.ActiveLoop
+	}
+	return nil
+}
+
+// StoryEntity holds an entity of a user message in a story.
+type StoryEntity struct {
+	// Entity holds the name of the entity.
+	Entity string
+
+	// Value holds the value of the entity. A nil value means the entity
+	// was extracted with any value.
+	Value interface{}
+
+	// Role holds the role of the entity.
+	Role string
+
+	// Group holds the group of the entity.
+	Group string
+}
+
+// ensure interface
+var _ yaml.Marshaler = StoryEntity{}
+var _ yaml.Unmarshaler = (*StoryEntity)(nil)
+
+// MarshalYAML implements yaml.Marshaler.
+//
+// Entities are written as `city: Paris`, or as `city` without a value.
+// Entities with a role or group are written with explicit keys.
+func (e StoryEntity) MarshalYAML() (interface{}, error) {
+	if e.Role == "" && e.Group == "" {
+		if e.Value == nil {
+			return e.Entity, nil
+		}
+		return yaml.MapSlice{{Key: e.Entity, Value: e.Value}}, nil
+	}
+
+	m := yaml.MapSlice{{Key: "entity", Value: e.Entity}}
+	if e.Value != nil {
+		m = append(m, yaml.MapItem{Key: "value", Value: e.Value})
+	}
+	if e.Role != "" {
+		m = append(m, yaml.MapItem{Key: "role", Value: e.Role})
+	}
+	if e.Group != "" {
+		m = append(m, yaml.MapItem{Key: "group", Value: e.Group})
+	}
+	return m, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *StoryEntity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*e = StoryEntity{Entity: name}
+		return nil
+	}
+
+	var m yaml.MapSlice
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+
+	explicit := false
+	for i := range m {
+		explicit = explicit || m[i].Key == "entity"
+	}
+	if !explicit {
+		if len(m) != 1 {
+			return perrors.Errorf("invalid entity with %d keys", len(m))
+		}
+		value, err := yamlToJSONValue(m[0].Value)
+		if err != nil {
+			return err
+		}
+		*e = StoryEntity{Entity: fmt.Sprint(m[0].Key), Value: value}
+		return nil
+	}
+
+	*e = StoryEntity{}
+	for i := range m {
+		switch m[i].Key {
+		case "entity":
+			e.Entity = fmt.Sprint(m[i].Value)
+		case "value":
+			value, err := yamlToJSONValue(m[i].Value)
+			if err != nil {
+				return err
+			}
+			e.Value = value
+		case "role":
+			e.Role = fmt.Sprint(m[i].Value)
+		case "group":
+			e.Group = fmt.Sprint(m[i].Value)
+		}
+	}
+	return nil
+}
+
+// StorySlot holds a slot which was set in a story.
+type StorySlot struct {
+	// Name holds the name of the slot.
+	Name string
+
+	// Value holds the value of the slot.
+	Value interface{}
+}
+
+// ensure interface
+var _ yaml.Marshaler = StorySlot{}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s StorySlot) MarshalYAML() (interface{}, error) {
+	return yaml.MapSlice{{Key: s.Name, Value: s.Value}}, nil
+}
+
+// storySlotItem holds an item of a slot_was_set step, which is either the
+// name of a slot, or a mapping of slots to their values.
+type storySlotItem []StorySlot
+
+// ensure interface
+var _ yaml.Unmarshaler = (*storySlotItem)(nil)
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *storySlotItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*s = storySlotItem{{Name: name, Value: SlotValueFilled}}
+		return nil
+	}
+
+	var m yaml.MapSlice
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	*s = make(storySlotItem, len(m))
+	for i := range m {
+		value, err := yamlToJSONValue(m[i].Value)
+		if err != nil {
+			return err
+		}
+		(*s)[i] = StorySlot{Name: fmt.Sprint(m[i].Key), Value: value}
+	}
+	return nil
+}
+
+// yamlToJSONValue converts a value decoded by yaml.v2 into the types used
+// for decoded JSON, such as float64 and map[string]interface{}.
+func yamlToJSONValue(value interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	if err := writeYAMLAsJSON(&buf, value); err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	err := json.Unmarshal(buf.Bytes(), &result)
+	return result, err
+}
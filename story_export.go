@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Story returns the applied events of the tracker as a story, the same way
+// Rasa does when exporting a conversation with the StoryExported event. The
+// name defaults to the sender id.
+//
+// Test stories hold the text of the user messages, with the entities
+// annotated, which allows using a captured conversation as a regression test
+// with `rasa test`.
+func (t *Tracker) Story(name string, test bool) Story {
+	if name == "" {
+		name = t.SenderID
+	}
+	return Story{
+		Name:  name,
+		Steps: t.AppliedEvents().StorySteps(test),
+	}
+}
+
+// ExportStories returns the YAML training data file holding the story of
+// the tracker. See Tracker.Story.
+func (t *Tracker) ExportStories(test bool) ([]byte, error) {
+	file := StoryFile{Stories: []Story{t.Story("", test)}}
+	return file.Marshal()
+}
+
+// StorySteps converts the events into the steps of a story.
+//
+// User messages are converted into intents with their entities, or into the
+// annotated text of the message for test stories. Executed actions other
+// than action_listen, slots, and loops are converted into their steps.
+// Consecutive slots are merged into a single step. Other events, such as
+// the messages of the bot, are not part of stories.
+func (events Events) StorySteps(test bool) (steps []StoryStep) {
+	for i := range events {
+		switch e := eventPointer(events[i]).(type) {
+		case *UserUttered:
+			steps = append(steps, userStoryStep(e, test))
+		case *ActionExecuted:
+			switch {
+			case e.ActionName == ActionListen:
+			case e.ActionName == "" && e.ActionText != "":
+				steps = append(steps, StoryStep{Bot: e.ActionText})
+			case e.ActionName != "":
+				steps = append(steps, StoryStep{Action: e.ActionName})
+			}
+		case *SlotSet:
+			slot := StorySlot{Name: e.Key, Value: e.Value}
+			if n := len(steps); n > 0 && len(steps[n-1].SlotWasSet) > 0 {
+				steps[n-1].SlotWasSet = append(steps[n-1].SlotWasSet, slot)
+				continue
+			}
+			steps = append(steps, StoryStep{SlotWasSet: []StorySlot{slot}})
+		case *ActiveLoop:
+			name := e.Name
+			steps = append(steps, StoryStep{ActiveLoop: &name})
+		}
+	}
+	return
+}
+
+// userStoryStep converts a user message into a step.
+func userStoryStep(e *UserUttered, test bool) (step StoryStep) {
+	var entities []Entity
+	if e.ParseData != nil {
+		step.Intent = e.ParseData.Intent.Name
+		entities = e.ParseData.Entities
+	}
+
+	if (test || step.Intent == "") && e.Text != "" && !IsIntentPayload(e.Text) {
+		step.User = annotateEntities(e.Text, entities)
+		return
+	}
+
+	for i := range entities {
+		step.Entities = append(step.Entities, StoryEntity{
+			Entity: entities[i].Entity,
+			Value:  entities[i].Value,
+			Role:   entities[i].Role,
+			Group:  entities[i].Group,
+		})
+	}
+	return
+}
+
+// annotateEntities annotates the entities in the text with Rasa's markdown
+// syntax, such as `[Paris](city)`. Entities with invalid or overlapping
+// offsets are not annotated.
+//
+// The offsets of entities are indices of characters rather than bytes, as
+// Rasa uses Python strings.
+func annotateEntities(text string, entities []Entity) string {
+	if len(entities) == 0 {
+		return text
+	}
+
+	sorted := make([]*Entity, len(entities))
+	for i := range entities {
+		sorted[i] = &entities[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	runes := []rune(text)
+	var b strings.Builder
+	pos := 0
+	for _, e := range sorted {
+		if e.Start < pos || e.End > len(runes) || e.Start >= e.End {
+			continue
+		}
+
+		b.WriteString(string(runes[pos:e.Start]))
+		b.WriteByte('[')
+		b.WriteString(string(runes[e.Start:e.End]))
+		b.WriteByte(']')
+		b.WriteString(entityMarkup(e, string(runes[e.Start:e.End])))
+		pos = e.End
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String()
+}
+
+// entityMarkup returns the annotation of the entity following its text,
+// which is either `(entity)` or a JSON object as written by Rasa.
+func entityMarkup(e *Entity, text string) string {
+	str, isString := e.Value.(string)
+	sameValue := e.Value == nil || (isString && str == text)
+	if sameValue && e.Role == "" && e.Group == "" {
+		return "(" + e.Entity + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString(`{"entity": `)
+	b.Write(appendJSONString(nil, e.Entity))
+	if !sameValue {
+		if value, err := json.Marshal(e.Value); err == nil {
+			b.WriteString(`, "value": `)
+			b.Write(value)
+		}
+	}
+	if e.Role != "" {
+		b.WriteString(`, "role": `)
+		b.Write(appendJSONString(nil, e.Role))
+	}
+	if e.Group != "" {
+		b.WriteString(`, "group": `)
+		b.Write(appendJSONString(nil, e.Group))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testStoryTracker returns the tracker of a conversation filling a form.
+func testStoryTracker() *Tracker {
+	return &Tracker{
+		SenderID: "default",
+		Events: Events{
+			&ActionExecuted{ActionName: ActionSessionStart},
+			&SessionStarted{},
+			&ActionExecuted{ActionName: ActionListen},
+			&UserUttered{
+				Text: "book a table for two in Paris",
+				ParseData: &ParseResult{
+					Intent: Intent{Name: "request_restaurant", Confidence: 0.98},
+					Entities: []Entity{
+						{Start: 24, End: 29, Value: "Paris", Entity: "city", Role: "destination"},
+						{Start: 17, End: 20, Value: 2.0, Entity: "number", Extractor: ExtractorDuckling},
+					},
+				},
+			},
+			&ActionExecuted{ActionName: "restaurant_form"},
+			&ActiveLoop{Name: "restaurant_form"},
+			&SlotSet{Key: "city", Value: "Paris"},
+			&SlotSet{Key: "people", Value: 2.0},
+			&SlotSet{Key: "requested_slot", Value: "cuisine"},
+			&BotUttered{Text: "Which cuisine?"},
+			&ActionExecuted{ActionName: ActionListen},
+			&UserUttered{
+				Text: "/inform{\"cuisine\":\"français\"}",
+				ParseData: &ParseResult{
+					Intent:   Intent{Name: "inform", Confidence: 1},
+					Entities: []Entity{{Value: "français", Entity: "cuisine"}},
+				},
+			},
+			&ActionExecuted{ActionName: "restaurant_form"},
+			&SlotSet{Key: "cuisine", Value: "français"},
+			&SlotSet{Key: "requested_slot", Value: nil},
+			&ActiveLoop{},
+			&ActionExecuted{ActionText: "Your table is booked!"},
+			&ActionExecuted{ActionName: ActionListen},
+			&UserUttered{Text: "thanks a lot"},
+		},
+	}
+}
+
+// TestTrackerStory
+func TestTrackerStory(t *testing.T) {
+	cases := []struct {
+		test   bool
+		golden string
+	}{
+		{test: false, golden: "testdata/stories/exported.yml"},
+		{test: true, golden: "testdata/stories/exported_test.yml"},
+	}
+
+	for i := range cases {
+		expect, err := ioutil.ReadFile(cases[i].golden)
+		require.NoErrorf(t, err, "failed on %d", i)
+
+		data, err := testStoryTracker().ExportStories(cases[i].test)
+		require.NoErrorf(t, err, "failed on %d", i)
+		require.Equalf(t, string(expect), string(data), "failed on %d", i)
+	}
+
+	story := testStoryTracker().Story("booking", false)
+	require.Equal(t, "booking", story.Name)
+	require.Equal(t, "request_restaurant", story.Steps[0].Intent)
+}
+
+// TestAnnotateEntities
+func TestAnnotateEntities(t *testing.T) {
+	cases := []struct {
+		text     string
+		entities []Entity
+		expect   string
+	}{
+		{
+			text:   "hello",
+			expect: "hello",
+		},
+		{
+			text:     "fly to Zürich from Paris",
+			entities: []Entity{{Start: 7, End: 13, Entity: "city", Value: "Zürich"}},
+			expect:   "fly to [Zürich](city) from Paris",
+		},
+		{
+			text: "fly to Zürich from Paris",
+			entities: []Entity{
+				{Start: 19, End: 24, Entity: "city", Value: "Paris", Role: "origin"},
+				{Start: 7, End: 13, Entity: "city", Value: "zurich", Group: "1"},
+			},
+			expect: `fly to [Zürich]{"entity": "city", "value": "zurich", "group": "1"} ` +
+				`from [Paris]{"entity": "city", "role": "origin"}`,
+		},
+		{
+			// overlapping and invalid offsets are ignored
+			text: "two tickets",
+			entities: []Entity{
+				{Start: 0, End: 3, Entity: "number", Value: 2.0},
+				{Start: 0, End: 11, Entity: "order"},
+				{Start: 4, End: 40, Entity: "item"},
+			},
+			expect: `[two]{"entity": "number", "value": 2} tickets`,
+		},
+	}
+
+	for i := range cases {
+		require.Equalf(t, cases[i].expect, annotateEntities(cases[i].text, cases[i].entities), "failed on %d", i)
+	}
+}
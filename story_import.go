@@ -0,0 +1,408 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ParseStories parses the stories and rules of a YAML training data file.
+func ParseStories(data []byte) (*StoryFile, error) {
+	file := new(StoryFile)
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, perrors.WithMessage(err, "invalid stories")
+	}
+	return file, nil
+}
+
+// LoadStories loads the stories and rules of the YAML training data files at
+// the provided paths, such as the `data` directory of a Rasa project.
+//
+// Directories are searched recursively for `.yml` and `.yaml` files. Files
+// without stories or rules, such as NLU data, are skipped. The version of
+// the result is the version of the first file.
+func LoadStories(paths ...string) (*StoryFile, error) {
+	var files []string
+	for _, path := range paths {
+		found, err := storyFiles(path)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "unable to read stories from [%s]", path)
+		}
+		files = append(files, found...)
+	}
+
+	result := new(StoryFile)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		part, err := ParseStories(data)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "in file [%s]", file)
+		}
+
+		if result.Version == "" {
+			result.Version = part.Version
+		}
+		result.Stories = append(result.Stories, part.Stories...)
+		result.Rules = append(result.Rules, part.Rules...)
+	}
+	return result, nil
+}
+
+// storyFiles returns the YAML files at path. If path is a file, it is
+// returned as is.
+func storyFiles(path string) (files []string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(file); !info.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return
+}
+
+// Story returns the story with the provided name, or nil.
+func (f *StoryFile) Story(name string) *Story {
+	for i := range f.Stories {
+		if f.Stories[i].Name == name {
+			return &f.Stories[i]
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule with the provided name, or nil.
+func (f *StoryFile) Rule(name string) *Rule {
+	for i := range f.Rules {
+		if f.Rules[i].Name == name {
+			return &f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// StoryEvents returns the event sequences of the story with the provided
+// name. Unlike Story.Events, checkpoints at the start of the story are
+// resolved, by prefixing the story with every story of the file which ends
+// with the checkpoint.
+func (f *StoryFile) StoryEvents(name string) ([]Events, error) {
+	story := f.Story(name)
+	if story == nil {
+		return nil, perrors.Errorf("story [%s] not found", name)
+	}
+	return f.resolveCheckpoints(story, map[string]bool{name: true}), nil
+}
+
+// resolveCheckpoints returns the event sequences of the story, prefixed by
+// the stories leading to its checkpoints. Stories which are visited already
+// are skipped to avoid cycles.
+func (f *StoryFile) resolveCheckpoints(story *Story, visited map[string]bool) []Events {
+	paths := story.Events()
+
+	var prefixes []Events
+	for _, checkpoint := range leadingCheckpoints(story.Steps) {
+		for i := range f.Stories {
+			prev := &f.Stories[i]
+			if visited[prev.Name] || !sliceContains(trailingCheckpoints(prev.Steps), checkpoint) {
+				continue
+			}
+
+			visited[prev.Name] = true
+			prefixes = append(prefixes, f.resolveCheckpoints(prev, visited)...)
+			delete(visited, prev.Name)
+		}
+	}
+	if len(prefixes) == 0 {
+		return paths
+	}
+
+	result := make([]Events, 0, len(prefixes)*len(paths))
+	for _, prefix := range prefixes {
+		for _, path := range paths {
+			result = append(result, joinEvents(prefix, path))
+		}
+	}
+	return result
+}
+
+// leadingCheckpoints returns the checkpoints at the start of the steps.
+func leadingCheckpoints(steps []StoryStep) (names []string) {
+	for i := range steps {
+		if steps[i].Checkpoint == "" {
+			break
+		}
+		names = append(names, steps[i].Checkpoint)
+	}
+	return
+}
+
+// trailingCheckpoints returns the checkpoints at the end of the steps.
+func trailingCheckpoints(steps []StoryStep) (names []string) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Checkpoint == "" {
+			break
+		}
+		names = append(names, steps[i].Checkpoint)
+	}
+	return
+}
+
+// joinEvents returns a new sequence of the events of a followed by the events
+// of b. An action_listen at the start of b is dropped if a ends with one.
+func joinEvents(a, b Events) Events {
+	if isActionListen(lastEvent(a)) && len(b) > 0 && isActionListen(b[0]) {
+		b = b[1:]
+	}
+	result := make(Events, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+// Events returns the event sequences described by the story. A story with
+// `or` steps describes a sequence for every alternative.
+//
+// Every user message is preceded by an action_listen, as in a tracker, and
+// is converted into a UserUttered event. Messages without text use the intent
+// payload as text, such as `/inform{"city":"Paris"}`. Entities annotated in
+// the text of the message are extracted with their offsets. Checkpoints are
+// ignored.
+func (s *Story) Events() []Events {
+	return stepEvents([]Events{nil}, s.Steps)
+}
+
+// Trackers returns a tracker for every event sequence of the story, with
+// the name of the story as sender id.
+func (s *Story) Trackers() []*Tracker {
+	return newTrackers(s.Name, s.Events())
+}
+
+// Events returns the event sequences described by the rule, including its
+// conditions. See Story.Events.
+//
+// Rules which apply at the start of a conversation are preceded by the
+// events of a session start. Unless the rule does not wait for user input,
+// an action_listen is added after the last action.
+func (r *Rule) Events() []Events {
+	var start Events
+	if r.ConversationStart {
+		start = Events{
+			&ActionExecuted{ActionName: ActionSessionStart},
+			&SessionStarted{},
+		}
+	}
+
+	paths := stepEvents(stepEvents([]Events{start}, r.Condition), r.Steps)
+	if r.WaitForUserInput != nil && !*r.WaitForUserInput {
+		return paths
+	}
+	for i := range paths {
+		if e, ok := lastEvent(paths[i]).(*ActionExecuted); ok && e.ActionName != ActionListen {
+			paths[i] = append(paths[i], &ActionExecuted{ActionName: ActionListen})
+		}
+	}
+	return paths
+}
+
+// Trackers returns a tracker for every event sequence of the rule, with the
+// name of the rule as sender id.
+func (r *Rule) Trackers() []*Tracker {
+	return newTrackers(r.Name, r.Events())
+}
+
+// newTrackers returns a tracker for every event sequence.
+func newTrackers(senderID string, paths []Events) []*Tracker {
+	trackers := make([]*Tracker, len(paths))
+	for i := range paths {
+		trackers[i] = &Tracker{SenderID: senderID, Slots: Slots{}}
+		trackers[i].ReplayFrom(paths[i])
+	}
+	return trackers
+}
+
+// stepEvents appends the events of the steps to every sequence, and returns
+// the resulting sequences.
+func stepEvents(paths []Events, steps []StoryStep) []Events {
+	for i := range steps {
+		if len(steps[i].Or) == 0 {
+			for j := range paths {
+				paths[j] = appendStepEvents(paths[j], &steps[i])
+			}
+			continue
+		}
+
+		next := make([]Events, 0, len(paths)*len(steps[i].Or))
+		for j := range paths {
+			for k := range steps[i].Or {
+				path := make(Events, len(paths[j]), len(paths[j])+2)
+				copy(path, paths[j])
+				next = append(next, appendStepEvents(path, &steps[i].Or[k]))
+			}
+		}
+		paths = next
+	}
+	return paths
+}
+
+// appendStepEvents appends the events of a single step.
+func appendStepEvents(events Events, step *StoryStep) Events {
+	if step.User != "" || step.Intent != "" {
+		if !isActionListen(lastEvent(events)) {
+			events = append(events, &ActionExecuted{ActionName: ActionListen})
+		}
+		events = append(events, userStepEvent(step))
+	}
+	if step.Action != "" {
+		events = append(events, &ActionExecuted{ActionName: step.Action})
+	}
+	if step.Bot != "" {
+		events = append(events, &ActionExecuted{ActionText: step.Bot})
+	}
+	for i := range step.SlotWasSet {
+		events = append(events, &SlotSet{Key: step.SlotWasSet[i].Name, Value: step.SlotWasSet[i].Value})
+	}
+	if step.ActiveLoop != nil {
+		events = append(events, &ActiveLoop{Name: *step.ActiveLoop})
+	}
+	return events
+}
+
+// userStepEvent converts a user step into a UserUttered event.
+func userStepEvent(step *StoryStep) *UserUttered {
+	text, entities := parseAnnotatedText(step.User)
+	for i := range step.Entities {
+		entities = append(entities, Entity{
+			Entity: step.Entities[i].Entity,
+			Value:  step.Entities[i].Value,
+			Role:   step.Entities[i].Role,
+			Group:  step.Entities[i].Group,
+		})
+	}
+
+	if text == "" {
+		payload := IntentPayload{Intent: step.Intent}
+		for i := range step.Entities {
+			if step.Entities[i].Value == nil {
+				continue
+			}
+			if payload.Entities == nil {
+				payload.Entities = make(JSONMap)
+			}
+			payload.Entities[step.Entities[i].Entity] = step.Entities[i].Value
+		}
+		text = payload.String()
+	}
+
+	parse := &ParseResult{Text: text, Entities: entities}
+	if step.Intent != "" {
+		parse.Intent = Intent{Name: step.Intent, Confidence: 1}
+	}
+	return &UserUttered{Text: text, ParseData: parse}
+}
+
+// entityAnnotationPattern matches the entities annotated in the text of user
+// messages, such as `[Paris](city)`, `[paris](city:Paris)` and
+// `[Paris]{"entity": "city", "role": "destination"}`.
+var entityAnnotationPattern = regexp.MustCompile(
+	`\[([^\]]+?)\](?:\(([^:)]+?)(?::([^)]+))?\)|(\{[^}]+?\}))`,
+)
+
+// parseAnnotatedText removes the entity annotations from the text, and
+// returns the text with the annotated entities. The offsets of the entities
+// are indices of characters, as used by Rasa.
+func parseAnnotatedText(annotated string) (string, []Entity) {
+	matches := entityAnnotationPattern.FindAllStringSubmatchIndex(annotated, -1)
+	if len(matches) == 0 {
+		return annotated, nil
+	}
+
+	var b strings.Builder
+	var entities []Entity
+	pos, offset := 0, 0
+	for _, m := range matches {
+		before := annotated[pos:m[0]]
+		text := annotated[m[2]:m[3]]
+		b.WriteString(before)
+		offset += len([]rune(before))
+
+		entity := Entity{
+			Start: offset,
+			End:   offset + len([]rune(text)),
+			Value: text,
+			Text:  text,
+		}
+		switch {
+		case m[4] >= 0:
+			entity.Entity = annotated[m[4]:m[5]]
+			if m[6] >= 0 {
+				entity.Value = annotated[m[6]:m[7]]
+			}
+		default:
+			var props struct {
+				Entity string      `json:"entity"`
+				Value  interface{} `json:"value"`
+				Role   string      `json:"role"`
+				Group  string      `json:"group"`
+			}
+			if err := json.Unmarshal([]byte(annotated[m[8]:m[9]]), &props); err != nil {
+				// not an annotation, keep the text as is
+				b.WriteString(annotated[m[0]:m[1]])
+				offset += len([]rune(annotated[m[0]:m[1]]))
+				pos = m[1]
+				continue
+			}
+			entity.Entity, entity.Role, entity.Group = props.Entity, props.Role, props.Group
+			if props.Value != nil {
+				entity.Value = props.Value
+			}
+		}
+
+		b.WriteString(text)
+		offset = entity.End
+		pos = m[1]
+		entities = append(entities, entity)
+	}
+	b.WriteString(annotated[pos:])
+	return b.String(), entities
+}
+
+// lastEvent returns the last event, or nil.
+func lastEvent(events Events) Event {
+	if len(events) == 0 {
+		return nil
+	}
+	return events[len(events)-1]
+}
+
+// isActionListen returns whether the event is the execution of action_listen.
+func isActionListen(evt Event) bool {
+	if evt == nil {
+		return false
+	}
+	e, ok := eventPointer(evt).(*ActionExecuted)
+	return ok && e.ActionName == ActionListen
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadStories
+func TestLoadStories(t *testing.T) {
+	file, err := LoadStories("testdata/stories/project")
+	require.NoError(t, err)
+	require.Len(t, file.Rules, 2)
+	require.Len(t, file.Stories, 2)
+
+	story := file.Story("book a table")
+	require.NotNil(t, story)
+	require.Equal(t, "greeted", story.Steps[0].Checkpoint)
+	require.Equal(t, []StoryEntity{{Entity: "cuisine", Value: "french"}}, story.Steps[5].Or[0].Entities)
+	require.Equal(t, []StorySlot{
+		{Name: "cuisine", Value: SlotValueFilled},
+		{Name: "requested_slot", Value: nil},
+	}, story.Steps[7].SlotWasSet)
+	require.Equal(t, "", *story.Steps[8].ActiveLoop)
+
+	rule := file.Rule("submit form")
+	require.NotNil(t, rule)
+	require.Equal(t, "restaurant_form", *rule.Condition[0].ActiveLoop)
+	require.False(t, *rule.WaitForUserInput)
+
+	require.Nil(t, file.Story("missing"))
+	require.Nil(t, file.Rule("missing"))
+
+	_, err = LoadStories("testdata/stories/missing")
+	require.Error(t, err)
+
+	_, err = ParseStories([]byte("stories:\n- story: a\n  steps:\n  - unknown: x\n"))
+	require.Error(t, err)
+}
+
+// TestStoryEvents
+func TestStoryEvents(t *testing.T) {
+	file, err := LoadStories("testdata/stories/project/data/stories.yml")
+	require.NoError(t, err)
+
+	story := file.Story("book a table")
+	paths := story.Events()
+	require.Len(t, paths, 2)
+
+	require.Equal(t, &ActionExecuted{ActionName: ActionListen}, paths[0][0])
+	require.Equal(t, &UserUttered{
+		Text: "book a table in Paris for two",
+		ParseData: &ParseResult{
+			Text:   "book a table in Paris for two",
+			Intent: Intent{Name: "request_restaurant", Confidence: 1},
+			Entities: []Entity{
+				{Start: 16, End: 21, Value: "Paris", Text: "Paris", Entity: "city", Role: "destination"},
+				{Start: 26, End: 29, Value: "2", Text: "two", Entity: "number"},
+			},
+		},
+	}, paths[0][1])
+	require.Equal(t, &UserUttered{
+		Text: `/inform{"cuisine":"french"}`,
+		ParseData: &ParseResult{
+			Text:     `/inform{"cuisine":"french"}`,
+			Intent:   Intent{Name: "inform", Confidence: 1},
+			Entities: []Entity{{Value: "french", Entity: "cuisine"}},
+		},
+	}, paths[0][7])
+	require.Equal(t, "/affirm", paths[1][7].(*UserUttered).Text)
+	require.Len(t, paths[0], len(paths[1]))
+
+	trackers := story.Trackers()
+	require.Len(t, trackers, 2)
+	require.Equal(t, "book a table", trackers[0].SenderID)
+	require.Equal(t, Slots{"city": "Paris", "cuisine": SlotValueFilled, "requested_slot": nil}, trackers[0].Slots)
+	require.False(t, trackers[0].HasActiveLoop())
+	require.Equal(t, "utter_booked", trackers[0].LatestActionName)
+
+	// checkpoints are resolved with the stories leading to them
+	resolved, err := file.StoryEvents("book a table")
+	require.NoError(t, err)
+	require.Len(t, resolved, 2)
+	require.Equal(t, "greet", resolved[0][1].(*UserUttered).ParseData.Intent.Name)
+	require.Equal(t, &ActionExecuted{ActionName: "utter_greet"}, resolved[0][2])
+	require.Equal(t, &ActionExecuted{ActionName: ActionListen}, resolved[0][3])
+	require.Equal(t, len(paths[0])+3, len(resolved[0]))
+
+	_, err = file.StoryEvents("missing")
+	require.Error(t, err)
+}
+
+// TestRuleEvents
+func TestRuleEvents(t *testing.T) {
+	file, err := LoadStories("testdata/stories/project/data/rules.yml")
+	require.NoError(t, err)
+	require.Equal(t, "3.1", file.Version)
+
+	paths := file.Rule("say hello").Events()
+	require.Len(t, paths, 1)
+	require.Equal(t, Events{
+		&ActionExecuted{ActionName: ActionSessionStart},
+		&SessionStarted{},
+		&ActionExecuted{ActionName: ActionListen},
+		&UserUttered{
+			Text:      "/greet",
+			ParseData: &ParseResult{Text: "/greet", Intent: Intent{Name: "greet", Confidence: 1}},
+		},
+		&ActionExecuted{ActionName: "utter_greet"},
+		&ActionExecuted{ActionName: ActionListen},
+	}, paths[0])
+
+	rule := file.Rule("submit form")
+	paths = rule.Events()
+	require.Equal(t, Events{
+		&ActiveLoop{Name: "restaurant_form"},
+		&ActionExecuted{ActionName: "restaurant_form"},
+		&ActiveLoop{},
+		&SlotSet{Key: "requested_slot"},
+		&ActionExecuted{ActionName: "utter_submit"},
+	}, paths[0])
+
+	trackers := rule.Trackers()
+	require.Equal(t, "utter_submit", trackers[0].LatestActionName)
+}
+
+// TestStoryRoundTrip
+func TestStoryRoundTrip(t *testing.T) {
+	data, err := testStoryTracker().ExportStories(true)
+	require.NoError(t, err)
+
+	file, err := ParseStories(data)
+	require.NoError(t, err)
+	require.Len(t, file.Stories, 1)
+
+	paths := file.Stories[0].Events()
+	require.Len(t, paths, 1)
+	msg := paths[0][1].(*UserUttered)
+	require.Equal(t, "book a table for two in Paris", msg.Text)
+	require.Equal(t, testStoryTracker().Events[3].(*UserUttered).ParseData.Entities[0].Role, msg.ParseData.Entities[1].Role)
+
+	again, err := (&StoryFile{Stories: file.Stories}).Marshal()
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(again))
+}
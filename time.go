@@ -8,17 +8,21 @@ package rasa
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // zt is the undefined Zero Time variable
 var zt time.Time
 
-// Time provides a type alias around time.Time which serializes as an int64 for
-// proper interaction with Rasa.
+// Time provides a type alias around time.Time which serializes as a number of
+// seconds since the Unix epoch for proper interaction with Rasa.
 //
-// During both serializing and deserializing, all sub-second precision is
-// dropped.
+// Rasa's timestamps are floating point numbers. During both serializing and
+// deserializing, sub-second precision is kept up to microseconds.
 type Time time.Time
 
 // ensure interfaces
@@ -27,33 +31,163 @@ var _ json.Unmarshaler = (*Time)(nil)
 
 // UnmarshalJSON implements json.Unmarshaler.
 func (t *Time) UnmarshalJSON(data []byte) (err error) {
-	var seconds float64
-	if err = json.Unmarshal(data, &seconds); err != nil {
+	if string(data) == "null" {
+		*t = Time{}
 		return
 	}
 
-	if seconds == 0 {
+	sec, usec, err := parseTimestamp(string(data))
+	if err != nil {
+		return
+	}
+
+	if sec == 0 && usec == 0 {
 		// init to zero value
 		*t = Time{}
 		return
 	}
 
-	*t = Time(time.Unix(int64(seconds), 0))
+	*t = Time(time.Unix(sec, usec*int64(time.Microsecond)))
 	return nil
 }
 
 // MarshalJSON implements json.Marshaler.
 func (t Time) MarshalJSON() ([]byte, error) {
-	// only set if t has been set to a non-zero value
-	if tt := time.Time(t); !tt.IsZero() && tt != zt {
-		return json.Marshal(tt.Unix())
-	}
+	return t.appendJSON(make([]byte, 0, 24)), nil
+}
+
+// isZero returns whether t represents the zero time, which also includes the
+// Unix epoch itself.
+func (t Time) isZero() bool {
+	tt := time.Time(t)
+	return tt.IsZero() || tt.UnixNano() == 0
+}
 
+// appendJSON appends the JSON representation of t to b.
+func (t Time) appendJSON(b []byte) []byte {
 	// default serialize as 0, in stead of "nothing".
-	return json.Marshal(0)
+	if t.isZero() {
+		return append(b, '0')
+	}
+
+	tt := time.Time(t).Round(time.Microsecond)
+	sec, usec := tt.Unix(), int64(tt.Nanosecond())/int64(time.Microsecond)
+	if sec < 0 && usec != 0 {
+		// negative timestamps with a fraction are not produced by Rasa.
+		return appendJSONFloat(b, float64(sec)+float64(usec)/1e6)
+	}
+
+	b = strconv.AppendInt(b, sec, 10)
+	if usec == 0 {
+		return b
+	}
+
+	// write the fraction with 6 digits, and drop the trailing zeros
+	var frac [7]byte
+	frac[0] = '.'
+	for i := 6; i > 0; i-- {
+		frac[i] = byte('0' + usec%10)
+		usec /= 10
+	}
+	n := len(frac)
+	for frac[n-1] == '0' {
+		n--
+	}
+	return append(b, frac[:n]...)
+}
+
+// parseTimestamp parses a JSON number holding seconds since the Unix epoch,
+// rounded to microseconds.
+//
+// The digits are parsed directly to avoid the loss of precision of float64.
+func parseTimestamp(s string) (sec, usec int64, err error) {
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	if strings.ContainsAny(s, "eE-") || intPart == "" {
+		// exponent notation or negative values, fall back on float64
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err != nil {
+			return
+		}
+		sec = int64(math.Floor(f))
+		usec = int64(math.Round((f - math.Floor(f)) * 1e6))
+	} else {
+		if sec, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+			return
+		}
+
+		// take 7 digits, to round to 6
+		var digits [7]byte
+		for i := range digits {
+			digits[i] = '0'
+			if i < len(fracPart) {
+				if c := fracPart[i]; c < '0' || c > '9' {
+					err = fmt.Errorf("invalid timestamp [%s]", s)
+					return
+				}
+				digits[i] = fracPart[i]
+			}
+		}
+		for i := 0; i < 6; i++ {
+			usec = usec*10 + int64(digits[i]-'0')
+		}
+		if digits[6] >= '5' {
+			usec++
+		}
+	}
+
+	if usec >= 1e6 {
+		sec++
+		usec -= 1e6
+	}
+	return
 }
 
 // AsTime returns t as an instance of time.Time from the standard library.
 func (t Time) AsTime() time.Time {
 	return time.Time(t)
 }
+
+// isoTimeLayouts holds the layouts accepted by parseISOTime, in the order in
+// which they are tried. Fractional seconds are accepted by all layouts.
+var isoTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseISOTime parses the ISO 8601 representation of a date time, as written
+// by Python's datetime.isoformat.
+//
+// Values without an offset are interpreted as UTC. An empty string results in
+// the zero time.
+func parseISOTime(value string) (t time.Time, err error) {
+	if value == "" {
+		return
+	}
+	for _, layout := range isoTimeLayouts {
+		if t, err = time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// formatISOTime formats t the same way as Python's datetime.isoformat for a
+// timezone-aware datetime. Sub-second precision is kept up to microseconds.
+//
+// The zero time is formatted as an empty string.
+func formatISOTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.Nanosecond()/int(time.Microsecond) != 0 {
+		return t.Format("2006-01-02T15:04:05.000000-07:00")
+	}
+	return t.Format("2006-01-02T15:04:05-07:00")
+}
@@ -35,10 +35,10 @@ func TestTime(t *testing.T) {
 				json:   []byte("0"),
 			},
 			{
-				// see comment of previous case
+				// sub-second precision is kept up to microseconds
 				input:  time.Unix(0, 123456789),
-				output: time.Time{},
-				json:   []byte("0"),
+				output: time.Unix(0, 123457000),
+				json:   []byte("0.123457"),
 			},
 			{
 				input:  time.Unix(1, 0),
@@ -52,8 +52,19 @@ func TestTime(t *testing.T) {
 			},
 			{
 				input:  time.Unix(1234567890, 123456789),
-				output: time.Unix(1234567890, 0),
-				json:   []byte("1234567890"),
+				output: time.Unix(1234567890, 123457000),
+				json:   []byte("1234567890.123457"),
+			},
+			{
+				input:  time.Unix(1617181920, 500000000),
+				output: time.Unix(1617181920, 500000000),
+				json:   []byte("1617181920.5"),
+			},
+			{
+				// rounding carries over into the seconds
+				input:  time.Unix(1617181920, 999999600),
+				output: time.Unix(1617181921, 0),
+				json:   []byte("1617181921"),
 			},
 		}
 
@@ -71,4 +82,28 @@ func TestTime(t *testing.T) {
 			require.Equalf(t, Time(entry.output), result, "failed on %d", i)
 		}
 	})
+	t.Run("JSON unmarshal", func(t *testing.T) {
+		cases := []struct {
+			json   string
+			output time.Time
+		}{
+			{"null", time.Time{}},
+			{"0.0", time.Time{}},
+			{"1617181920.0", time.Unix(1617181920, 0)},
+			{"1617181920.123456", time.Unix(1617181920, 123456000)},
+			{"1617181920.1234567", time.Unix(1617181920, 123457000)},
+			{"1617181920.9999996", time.Unix(1617181921, 0)},
+			{"1.6171819201e9", time.Unix(1617181920, 100000000)},
+		}
+
+		for i := range cases {
+			var result Time
+			err := json.Unmarshal([]byte(cases[i].json), &result)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Truef(t, cases[i].output.Equal(result.AsTime()), "failed on %d: %v", i, result.AsTime())
+		}
+
+		var result Time
+		require.Error(t, json.Unmarshal([]byte(`"1617181920"`), &result))
+	})
 }
@@ -7,21 +7,29 @@
 package rasa
 
 import (
+	"encoding/json"
 	"fmt"
-	"reflect"
 )
 
 // Tracker contains the state of the Tracker sent to the action server by the
 // Rasa engine.
 type Tracker struct {
-	SenderID         string       `json:"sender_id"`
-	Slots            Slots        `json:"slots,omitempty"`
-	LatestMessage    *ParseResult `json:"latest_message,omitempty"`
-	LatestActionName string       `json:"latest_action_name,omitempty"`
-	Events           Events       `json:"events"`
-	Paused           bool         `json:"paused"`
-	FollowupAction   string       `json:"followup_action,omitempty"`
-	ActiveLoop       *TActiveLoop `json:"active_loop,omitempty"`
+	SenderID           string        `json:"sender_id"`
+	Slots              Slots         `json:"slots,omitempty"`
+	LatestMessage      *ParseResult  `json:"latest_message,omitempty"`
+	LatestEventTime    *Time         `json:"latest_event_time,omitempty"`
+	LatestInputChannel string        `json:"latest_input_channel,omitempty"`
+	LatestAction       *LatestAction `json:"latest_action,omitempty"`
+	LatestActionName   string        `json:"latest_action_name,omitempty"`
+	Events             Events        `json:"events"`
+	Paused             bool          `json:"paused"`
+	FollowupAction     string        `json:"followup_action,omitempty"`
+	ActiveLoop         *TActiveLoop  `json:"active_loop,omitempty"`
+
+	// InitialSlots holds the initial values of the slots, as defined in the
+	// domain. It is used when the slots are reset during the replay of
+	// events. Slots not present in InitialSlots are reset to nil.
+	InitialSlots Slots `json:"-"`
 }
 
 // HasSlots returns whether there are any Slots present in the Tracker.
@@ -93,25 +101,13 @@ func (t *Tracker) Slot(name string) (val interface{}, ok bool) {
 	return
 }
 
-// SlotAs attempts to assign the value of the slot to the `dst` pointer. The
-// `ok` flag indicates whether the slot was present, and successfully
-// assigned to `dst`.
+// SlotAs attempts to assign the value of the slot to the value pointed to by
+// `dst`. The `ok` flag indicates whether the slot was present, and
+// successfully assigned to `dst`.
 //
-// Passing a non-assignable type to `dst` leads to undefined behaviour.
+// SlotAs is a shorthand for SlotInto which discards the error.
 func (t *Tracker) SlotAs(name string, dst interface{}) (ok bool) {
-	val, exists := t.Slot(name)
-	if !exists {
-		return
-	}
-
-	rdst := reflect.ValueOf(dst)
-	rval := reflect.ValueOf(val)
-	if ok = rdst.CanSet() && rval.Type().AssignableTo(rdst.Type()); !ok {
-		return
-	}
-
-	rdst.Set(reflect.ValueOf(val))
-	return
+	return t.SlotInto(name, dst) == nil
 }
 
 // SlotsToValidate returns the slots which were recently set.
@@ -125,7 +121,7 @@ func (t *Tracker) SlotsToValidate() (slots Slots) {
 	for i := len(events) - 1; i >= 0; i-- {
 		// The `FormAction` in Rasa Open Source will append all slot candidates
 		// at the end of the tracker events.
-		if se, ok := events[i].(*SlotSet); ok {
+		if se, ok := eventPointer(events[i]).(*SlotSet); ok {
 			slots[se.Key] = se.Value
 			continue
 		}
@@ -138,6 +134,13 @@ func (t *Tracker) SlotsToValidate() (slots Slots) {
 	return
 }
 
+// LatestAction holds the latest executed action in the Tracker. For
+// end-to-end bot responses, ActionText is set instead of ActionName.
+type LatestAction struct {
+	ActionName string `json:"action_name,omitempty"`
+	ActionText string `json:"action_text,omitempty"`
+}
+
 // TActiveLoop holds a ActiveLoop description in the Tracker.
 type TActiveLoop struct {
 	Name           string       `json:"name"`
@@ -167,10 +170,13 @@ func (f *TActiveLoop) Is(name string) bool {
 
 // ParseResult holds a processed (parsed) message description.
 type ParseResult struct {
-	Intent        Intent   `json:"intent"`
-	IntentRanking []Intent `json:"intent_ranking,omitempty"`
-	Entities      []Entity `json:"entities,omitempty"`
-	Text          string   `json:"text,omitempty"`
+	Intent           Intent   `json:"intent"`
+	IntentRanking    []Intent `json:"intent_ranking,omitempty"`
+	Entities         []Entity `json:"entities,omitempty"`
+	Text             string   `json:"text,omitempty"`
+	MessageID        string   `json:"message_id,omitempty"`
+	Metadata         JSONMap  `json:"metadata,omitempty"`
+	ResponseSelector JSONMap  `json:"response_selector,omitempty"`
 }
 
 // Intent describes an intent and its detected confidence.
@@ -180,15 +186,80 @@ type Intent struct {
 }
 
 // Entity describes an entity and its detected location, value, and confidence.
+//
+// Depending on the extractor, the confidence is either stored in Confidence
+// (such as Duckling), or in ConfidenceEntity (such as DIET).
 type Entity struct {
-	Start      int         `json:"start"`
-	End        int         `json:"end"`
-	Value      interface{} `json:"value"`
-	Entity     string      `json:"entity"`
-	Confidence float64     `json:"confidence"`
-	Group      string      `json:"group,omitempty"`
-	Role       string      `json:"role,omitempty"`
-	Extractor  string      `json:"extractor"`
+	Start            int         `json:"start"`
+	End              int         `json:"end"`
+	Value            interface{} `json:"value"`
+	Entity           string      `json:"entity"`
+	Confidence       float64     `json:"confidence,omitempty"`
+	ConfidenceEntity float64     `json:"confidence_entity,omitempty"`
+	Group            string      `json:"group,omitempty"`
+	ConfidenceGroup  float64     `json:"confidence_group,omitempty"`
+	Role             string      `json:"role,omitempty"`
+	ConfidenceRole   float64     `json:"confidence_role,omitempty"`
+	Extractor        string      `json:"extractor,omitempty"`
+	Processors       []string    `json:"processors,omitempty"`
+	Text             string      `json:"text,omitempty"`
+	AdditionalInfo   interface{} `json:"additional_info,omitempty"`
+
+	// hasConfidence and hasConfidenceEntity indicate that the confidences
+	// were present in the JSON representation, even if they are zero.
+	hasConfidence       bool
+	hasConfidenceEntity bool
+}
+
+// entityJSON is the JSON representation of an Entity, in which the
+// confidences override the fields of the entity, so that their presence is
+// retained.
+type entityJSON struct {
+	*entityFields
+	Confidence       *float64 `json:"confidence,omitempty"`
+	ConfidenceEntity *float64 `json:"confidence_entity,omitempty"`
+}
+
+// entityFields prevents recursion when marshalling an Entity.
+type entityFields Entity
+
+// ensure interface
+var (
+	_ json.Marshaler   = Entity{}
+	_ json.Unmarshaler = (*Entity)(nil)
+)
+
+// MarshalJSON implements json.Marshaler.
+//
+// Confidences which were present when unmarshalling the entity are retained,
+// even if they are zero.
+func (e Entity) MarshalJSON() ([]byte, error) {
+	data := entityJSON{entityFields: (*entityFields)(&e)}
+	if e.hasConfidence || e.Confidence != 0 {
+		data.Confidence = &e.Confidence
+	}
+	if e.hasConfidenceEntity || e.ConfidenceEntity != 0 {
+		data.ConfidenceEntity = &e.ConfidenceEntity
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Entity) UnmarshalJSON(data []byte) error {
+	var result entityJSON
+	result.entityFields = (*entityFields)(&Entity{})
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*e = Entity(*result.entityFields)
+	if result.Confidence != nil {
+		e.Confidence, e.hasConfidence = *result.Confidence, true
+	}
+	if result.ConfidenceEntity != nil {
+		e.ConfidenceEntity, e.hasConfidenceEntity = *result.ConfidenceEntity, true
+	}
+	return nil
 }
 
 // Slots is a wrapper type around slots.
@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"reflect"
+	"time"
+)
+
+// SlotValue holds a value in the history of a slot.
+type SlotValue struct {
+	// Value holds the value of the slot.
+	Value interface{}
+
+	// Time holds the time of the event which changed the slot.
+	Time time.Time
+
+	// Index holds the index of the event which changed the slot in
+	// Tracker.Events.
+	Index int
+
+	// Event holds the type of the event which changed the slot, such as
+	// EventTypeSlotSet or EventTypeRestarted.
+	Event EventType
+
+	// Action holds the name of the latest action executed before the slot
+	// changed, which is the action that returned the event. It is empty for
+	// slots set before any action.
+	Action string
+}
+
+// SlotHistory returns the values of the slot in the order in which they were
+// set, including resets of the slot by restarts, session starts, and
+// AllSlotsReset events. Consecutive duplicate values are omitted.
+//
+// The history is built from all events of the tracker, so it includes the
+// values set by events which were reverted later, such as the value before
+// the user corrected it. Reverted user utterances and actions which change
+// the slot add the value they restore, so the last value in the history is
+// the current value of the slot.
+func (t *Tracker) SlotHistory(name string) (history []SlotValue) {
+	initial := t.InitialSlots[name]
+	current := initial
+	action := ""
+
+	for i := range t.Events {
+		value := current
+		switch e := eventPointer(t.Events[i]).(type) {
+		case *ActionExecuted:
+			action = e.ActionName
+			continue
+		case *SlotSet:
+			if e.Key != name {
+				continue
+			}
+			value = e.Value
+		case *AllSlotsReset, *Restarted, *SessionStarted:
+			value = initial
+		case *UserUtteranceReverted, *ActionReverted:
+			value = slotValue(appliedEvents(t.Events[:i+1]), name, initial)
+		default:
+			continue
+		}
+
+		isSlotSet := t.Events[i].Type() == EventTypeSlotSet
+		if !isSlotSet && reflect.DeepEqual(value, current) {
+			continue
+		}
+		if n := len(history); isSlotSet && n > 0 && reflect.DeepEqual(value, history[n-1].Value) {
+			current = value
+			continue
+		}
+
+		current = value
+		history = append(history, SlotValue{
+			Value:  value,
+			Time:   t.Events[i].Time(),
+			Index:  i,
+			Event:  t.Events[i].Type(),
+			Action: action,
+		})
+	}
+	return
+}
+
+// slotValue returns the value of the slot after the events, which must not
+// contain reverts, starting from the initial value.
+func slotValue(events Events, name string, initial interface{}) interface{} {
+	value := initial
+	for i := range events {
+		switch e := eventPointer(events[i]).(type) {
+		case *SlotSet:
+			if e.Key == name {
+				value = e.Value
+			}
+		case *AllSlotsReset, *Restarted, *SessionStarted:
+			value = initial
+		}
+	}
+	return value
+}
+
+// At returns a snapshot of the tracker after the first n events, which is
+// rebuilt by replaying them. At(0) returns the initial state, and
+// At(len(t.Events)) the current state. n is clamped to the number of events.
+//
+// The snapshot holds a copy of the events, and all slots known to the
+// tracker.
+func (t *Tracker) At(n int) *Tracker {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(t.Events) {
+		n = len(t.Events)
+	}
+
+	snapshot := &Tracker{
+		SenderID:     t.SenderID,
+		Slots:        make(Slots, len(t.Slots)),
+		InitialSlots: t.InitialSlots,
+	}
+	for key := range t.Slots {
+		snapshot.Slots[key] = nil
+	}
+	snapshot.ReplayFrom(t.Events[:n])
+	return snapshot
+}
+
+// AtTime returns a snapshot of the tracker after the events which happened
+// at or before ts. See Tracker.At.
+//
+// Events are assumed to be in chronological order; events without a
+// timestamp belong to the preceding event.
+func (t *Tracker) AtTime(ts time.Time) *Tracker {
+	n := 0
+	for i := range t.Events {
+		if evtTime := t.Events[i].Time(); !Time(evtTime).isZero() && evtTime.After(ts) {
+			break
+		}
+		n = i + 1
+	}
+	return t.At(n)
+}
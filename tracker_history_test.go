@@ -0,0 +1,219 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// historyTracker returns a tracker in which the city slot changes a number of
+// times, with an event every second.
+func historyTracker() *Tracker {
+	start := time.Unix(1600000000, 0)
+	events := Events{
+		&ActionExecuted{ActionName: ActionListen},
+		&UserUttered{Text: "to Paris", ParseData: &ParseResult{Intent: Intent{Name: "inform"}}},
+		&ActionExecuted{ActionName: "action_set_city"},
+		&SlotSet{Key: "city", Value: "Paris"},
+		&SlotSet{Key: "count", Value: 2.0},
+		&ActionExecuted{ActionName: "action_confirm"},
+		&SlotSet{Key: "city", Value: "Paris"},
+		&AllSlotsReset{},
+		&ActionExecuted{ActionName: "action_set_city"},
+		&SlotSet{Key: "city", Value: "Berlin"},
+		&Restarted{},
+	}
+	for i := range events {
+		setEventTime(events[i], start.Add(time.Duration(i)*time.Second))
+	}
+
+	tracker := &Tracker{SenderID: "history", InitialSlots: Slots{"count": 1.0}}
+	tracker.ReplayFrom(events)
+	return tracker
+}
+
+// setEventTime sets the timestamp of the event.
+func setEventTime(evt Event, ts time.Time) {
+	switch e := evt.(type) {
+	case *ActionExecuted:
+		e.Timestamp = Time(ts)
+	case *UserUttered:
+		e.Timestamp = Time(ts)
+	case *SlotSet:
+		e.Timestamp = Time(ts)
+	case *AllSlotsReset:
+		e.Timestamp = Time(ts)
+	case *Restarted:
+		e.Timestamp = Time(ts)
+	}
+}
+
+// TestTrackerSlotHistory
+func TestTrackerSlotHistory(t *testing.T) {
+	tracker := historyTracker()
+	start := time.Unix(1600000000, 0)
+
+	cases := []struct {
+		Slot     string
+		Expected []SlotValue
+	}{
+		{
+			Slot: "city",
+			Expected: []SlotValue{
+				{Value: "Paris", Time: start.Add(3 * time.Second), Index: 3, Event: EventTypeSlotSet, Action: "action_set_city"},
+				{Value: nil, Time: start.Add(7 * time.Second), Index: 7, Event: EventTypeAllSlotsReset, Action: "action_confirm"},
+				{Value: "Berlin", Time: start.Add(9 * time.Second), Index: 9, Event: EventTypeSlotSet, Action: "action_set_city"},
+				{Value: nil, Time: start.Add(10 * time.Second), Index: 10, Event: EventTypeRestarted, Action: "action_set_city"},
+			},
+		},
+		{
+			Slot: "count",
+			Expected: []SlotValue{
+				{Value: 2.0, Time: start.Add(4 * time.Second), Index: 4, Event: EventTypeSlotSet, Action: "action_set_city"},
+				{Value: 1.0, Time: start.Add(7 * time.Second), Index: 7, Event: EventTypeAllSlotsReset, Action: "action_confirm"},
+			},
+		},
+		{Slot: "unknown"},
+	}
+
+	for i, c := range cases {
+		history := tracker.SlotHistory(c.Slot)
+		require.Len(t, history, len(c.Expected), "failed on %d", i)
+		for j := range c.Expected {
+			require.Equal(t, c.Expected[j].Value, history[j].Value, "failed on %d:%d", i, j)
+			require.True(t, c.Expected[j].Time.Equal(history[j].Time), "failed on %d:%d", i, j)
+			require.Equal(t, c.Expected[j].Index, history[j].Index, "failed on %d:%d", i, j)
+			require.Equal(t, c.Expected[j].Event, history[j].Event, "failed on %d:%d", i, j)
+			require.Equal(t, c.Expected[j].Action, history[j].Action, "failed on %d:%d", i, j)
+		}
+	}
+}
+
+// TestTrackerSlotHistoryReverted
+func TestTrackerSlotHistoryReverted(t *testing.T) {
+	cases := []struct {
+		events Events
+		expect []interface{}
+	}{
+		{
+			events: Events{
+				&ActionExecuted{ActionName: ActionListen},
+				&UserUttered{Text: "to Paris"},
+				&ActionExecuted{ActionName: "action_x"},
+				&SlotSet{Key: "city", Value: "Paris"},
+				&BotUttered{Text: "reverted bot"},
+				&UserUtteranceReverted{},
+			},
+			expect: []interface{}{"Paris", nil},
+		},
+		{
+			// the user corrects the city
+			events: Events{
+				&ActionExecuted{ActionName: ActionListen},
+				&UserUttered{Text: "to Rome"},
+				&ActionExecuted{ActionName: "action_x"},
+				&SlotSet{Key: "city", Value: "Rome"},
+				&ActionExecuted{ActionName: ActionListen},
+				&UserUttered{Text: "to Paris"},
+				&ActionExecuted{ActionName: "action_x"},
+				&SlotSet{Key: "city", Value: "Paris"},
+				&ActionReverted{},
+			},
+			expect: []interface{}{"Rome", "Paris", "Rome"},
+		},
+		{
+			// reverts which do not change the slot are omitted
+			events: Events{
+				&ActionExecuted{ActionName: ActionListen},
+				&UserUttered{Text: "to Rome"},
+				&ActionExecuted{ActionName: "action_x"},
+				&SlotSet{Key: "city", Value: "Rome"},
+				&ActionExecuted{ActionName: "action_y"},
+				&ActionReverted{},
+			},
+			expect: []interface{}{"Rome"},
+		},
+	}
+
+	for i := range cases {
+		var tracker Tracker
+		tracker.ReplayFrom(cases[i].events)
+
+		history := tracker.SlotHistory("city")
+		values := make([]interface{}, len(history))
+		for j := range history {
+			values[j] = history[j].Value
+		}
+		require.Equalf(t, cases[i].expect, values, "failed on %d", i)
+		require.Equalf(t, tracker.Slots["city"], values[len(values)-1], "failed on %d", i)
+	}
+}
+
+// TestTrackerAt
+func TestTrackerAt(t *testing.T) {
+	tracker := historyTracker()
+
+	cases := []struct {
+		N            int
+		Events       int
+		Slots        Slots
+		LatestAction string
+	}{
+		{N: -1, Events: 0, Slots: Slots{"city": nil, "count": 1.0}},
+		{N: 0, Events: 0, Slots: Slots{"city": nil, "count": 1.0}},
+		{N: 4, Events: 4, Slots: Slots{"city": "Paris", "count": 1.0}, LatestAction: "action_set_city"},
+		{N: 5, Events: 5, Slots: Slots{"city": "Paris", "count": 2.0}, LatestAction: "action_set_city"},
+		{N: 8, Events: 8, Slots: Slots{"city": nil, "count": 1.0}, LatestAction: "action_confirm"},
+		{N: 10, Events: 10, Slots: Slots{"city": "Berlin", "count": 1.0}, LatestAction: "action_set_city"},
+		{N: 100, Events: 11, Slots: Slots{"city": nil, "count": 1.0}, LatestAction: ""},
+	}
+
+	for i, c := range cases {
+		snapshot := tracker.At(c.N)
+		require.Equal(t, "history", snapshot.SenderID, "failed on %d", i)
+		require.Len(t, snapshot.Events, c.Events, "failed on %d", i)
+		require.Equal(t, c.Slots, snapshot.Slots, "failed on %d", i)
+		require.Equal(t, c.LatestAction, snapshot.LatestActionName, "failed on %d", i)
+	}
+
+	// the snapshot does not share events with the tracker
+	snapshot := tracker.At(2)
+	snapshot.Apply(&SlotSet{Key: "city", Value: "Rome"})
+	require.Len(t, tracker.Events, 11)
+	require.Equal(t, EventTypeSlotSet, tracker.Events[3].Type())
+	require.Equal(t, "Paris", tracker.Events[3].(*SlotSet).Value)
+
+	// the current state matches the tracker
+	require.True(t, DiffTrackers(tracker, tracker.At(len(tracker.Events))).Empty())
+}
+
+// TestTrackerAtTime
+func TestTrackerAtTime(t *testing.T) {
+	tracker := historyTracker()
+	start := time.Unix(1600000000, 0)
+
+	cases := []struct {
+		Time   time.Time
+		Events int
+		City   interface{}
+	}{
+		{Time: start.Add(-time.Second), Events: 0},
+		{Time: start, Events: 1},
+		{Time: start.Add(3500 * time.Millisecond), Events: 4, City: "Paris"},
+		{Time: start.Add(9 * time.Second), Events: 10, City: "Berlin"},
+		{Time: start.Add(time.Hour), Events: 11},
+	}
+
+	for i, c := range cases {
+		snapshot := tracker.AtTime(c.Time)
+		require.Len(t, snapshot.Events, c.Events, "failed on %d", i)
+		require.Equal(t, c.City, snapshot.Slots["city"], "failed on %d", i)
+	}
+}
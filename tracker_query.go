@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+// EventsAfterLatestRestart returns the events which happened after the latest
+// Restarted or SessionStarted event. All events are returned if the
+// conversation was never restarted.
+func (t *Tracker) EventsAfterLatestRestart() Events {
+	for i := len(t.Events) - 1; i >= 0; i-- {
+		switch t.Events[i].Type() {
+		case EventTypeRestarted, EventTypeSessionStarted:
+			return t.Events[i+1:]
+		}
+	}
+	return t.Events
+}
+
+// AppliedEvents returns the events which are still in effect, taking
+// restarts, session starts, reverted user utterances, and reverted actions
+// into account.
+func (t *Tracker) AppliedEvents() Events {
+	return appliedEvents(t.Events)
+}
+
+// LastEventFor returns the latest applied event of the provided type, or nil
+// if no such event exists. Events which were undone, such as by a reverted
+// user utterance, are ignored.
+//
+// ActionExecuted events for the actions in skip are ignored, which allows
+// looking up the latest action other than e.g. `action_listen`. Lazy events
+// are decoded, and all events are returned as pointers.
+func (t *Tracker) LastEventFor(typ EventType, skip ...string) Event {
+	events := t.AppliedEvents()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type() != typ {
+			continue
+		}
+
+		evt := eventPointer(events[i])
+		if e, ok := evt.(*ActionExecuted); ok && sliceContains(skip, e.ActionName) {
+			continue
+		}
+		return evt
+	}
+	return nil
+}
+
+// LatestBotUtterance returns the latest message sent by the bot, or nil if the
+// bot did not send any messages.
+func (t *Tracker) LatestBotUtterance() *BotUttered {
+	if e, ok := t.LastEventFor(EventTypeBotUttered).(*BotUttered); ok {
+		return e
+	}
+	return nil
+}
+
+// LatestIntent returns the name of the intent of the latest user message.
+//
+// If skipFallback is set and the latest intent is `nlu_fallback`, the name of
+// the intent ranked second is returned instead.
+func (t *Tracker) LatestIntent(skipFallback bool) string {
+	if t.LatestMessage == nil {
+		return ""
+	}
+
+	intent := t.LatestMessage.Intent.Name
+	if skipFallback && intent == IntentNLUFallback {
+		ranking := t.LatestMessage.IntentRanking
+		if len(ranking) > 1 {
+			return ranking[1].Name
+		}
+		return ""
+	}
+	return intent
+}
+
+// Turn holds a single turn of the conversation: a user message, and the
+// events which followed it up to the next user message.
+type Turn struct {
+	// User holds the message of the user which started the turn. User is nil
+	// for the events which happened before the first user message.
+	User *UserUttered
+
+	// Events holds the events which followed the user message.
+	Events Events
+}
+
+// Actions returns the names of the actions executed during the turn,
+// excluding `action_listen`.
+func (t *Turn) Actions() (actions []string) {
+	for i := range t.Events {
+		if e, ok := eventPointer(t.Events[i]).(*ActionExecuted); ok && e.ActionName != ActionListen {
+			actions = append(actions, e.ActionName)
+		}
+	}
+	return
+}
+
+// BotUtterances returns the messages sent by the bot during the turn.
+func (t *Turn) BotUtterances() (utterances []*BotUttered) {
+	for i := range t.Events {
+		if e, ok := eventPointer(t.Events[i]).(*BotUttered); ok {
+			utterances = append(utterances, e)
+		}
+	}
+	return
+}
+
+// Turns splits the applied events of the Tracker into conversation turns.
+//
+// Events which happened before the first user message are returned as a turn
+// without a user message.
+func (t *Tracker) Turns() (turns []Turn) {
+	var turn *Turn
+	for _, evt := range t.AppliedEvents() {
+		if e, ok := eventPointer(evt).(*UserUttered); ok {
+			turns = append(turns, Turn{User: e})
+			turn = &turns[len(turns)-1]
+			continue
+		}
+
+		if turn == nil {
+			turns = append(turns, Turn{})
+			turn = &turns[len(turns)-1]
+		}
+		turn.Events = append(turn.Events, evt)
+	}
+	return
+}
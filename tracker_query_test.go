@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrackerQuery
+func TestTrackerQuery(t *testing.T) {
+	greet := &UserUttered{
+		Text:      "hello",
+		ParseData: &ParseResult{Intent: Intent{Name: "greet", Confidence: 1}},
+	}
+	inform := &UserUttered{
+		Text:      "in Paris",
+		ParseData: &ParseResult{Intent: Intent{Name: "inform", Confidence: 1}},
+	}
+	hi := &BotUttered{Text: "Hi!"}
+	where := &BotUttered{Text: "Where to?"}
+
+	var tracker Tracker
+	tracker.ReplayFrom(Events{
+		&ActionExecuted{ActionName: ActionListen},
+		&UserUttered{Text: "before restart"},
+		&Restarted{},
+		&ActionExecuted{ActionName: ActionSessionStart},
+		&SessionStarted{},
+		&ActionExecuted{ActionName: ActionListen},
+		greet,
+		&ActionExecuted{ActionName: "utter_greet"},
+		hi,
+		&ActionExecuted{ActionName: ActionListen},
+		inform,
+		&SlotSet{Key: "city", Value: "Paris"},
+		&ActionExecuted{ActionName: "utter_ask_date"},
+		where,
+		&ActionExecuted{ActionName: ActionListen},
+	})
+
+	t.Run("EventsAfterLatestRestart", func(t *testing.T) {
+		events := tracker.EventsAfterLatestRestart()
+		require.Len(t, events, 10)
+		require.Equal(t, &ActionExecuted{ActionName: ActionListen}, events[0])
+
+		empty := Tracker{Events: Events{greet}}
+		require.Equal(t, Events{greet}, empty.EventsAfterLatestRestart())
+	})
+
+	t.Run("AppliedEvents", func(t *testing.T) {
+		reverted := Tracker{Events: append(append(Events{}, tracker.Events...), &UserUtteranceReverted{})}
+		applied := reverted.AppliedEvents()
+		require.Len(t, applied, 4)
+		require.Equal(t, hi, applied[len(applied)-1])
+	})
+
+	t.Run("LastEventFor", func(t *testing.T) {
+		require.Equal(t, &ActionExecuted{ActionName: ActionListen}, tracker.LastEventFor(EventTypeActionExecuted))
+		require.Equal(t, &ActionExecuted{ActionName: "utter_ask_date"}, tracker.LastEventFor(EventTypeActionExecuted, ActionListen))
+		require.Equal(t, inform, tracker.LastEventFor(EventTypeUserUttered))
+		require.Nil(t, tracker.LastEventFor(EventTypeActiveLoop))
+	})
+
+	t.Run("LatestBotUtterance", func(t *testing.T) {
+		require.Equal(t, where, tracker.LatestBotUtterance())
+		require.Nil(t, (&Tracker{}).LatestBotUtterance())
+	})
+
+	t.Run("reverted", func(t *testing.T) {
+		var reverted Tracker
+		reverted.ReplayFrom(Events{
+			&ActionExecuted{ActionName: ActionListen},
+			&UserUttered{Text: "hi", InputChannel: "slack"},
+			&ActionExecuted{ActionName: "action_x"},
+			&SlotSet{Key: "city", Value: "Paris"},
+			&BotUttered{Text: "reverted bot"},
+			&UserUtteranceReverted{},
+		})
+		require.Empty(t, reverted.AppliedEvents())
+		require.Nil(t, reverted.LastEventFor(EventTypeBotUttered))
+		require.Nil(t, reverted.LastEventFor(EventTypeUserUttered))
+		require.Nil(t, reverted.LatestBotUtterance())
+
+		reverted.Apply(&ActionExecuted{ActionName: "action_y"}, &BotUttered{Text: "bot"}, &ActionReverted{})
+		require.Nil(t, reverted.LatestBotUtterance())
+	})
+
+	t.Run("LatestIntent", func(t *testing.T) {
+		require.Equal(t, "inform", tracker.LatestIntent(true))
+
+		fallback := Tracker{LatestMessage: &ParseResult{
+			Intent:        Intent{Name: IntentNLUFallback, Confidence: 0.7},
+			IntentRanking: []Intent{{Name: IntentNLUFallback, Confidence: 0.7}, {Name: "affirm", Confidence: 0.3}},
+		}}
+		require.Equal(t, IntentNLUFallback, fallback.LatestIntent(false))
+		require.Equal(t, "affirm", fallback.LatestIntent(true))
+		require.Equal(t, "", (&Tracker{}).LatestIntent(true))
+	})
+
+	t.Run("Turns", func(t *testing.T) {
+		turns := tracker.Turns()
+		require.Len(t, turns, 3)
+
+		require.Nil(t, turns[0].User)
+		require.Equal(t, Events{&ActionExecuted{ActionName: ActionListen}}, turns[0].Events)
+		require.Empty(t, turns[0].Actions())
+
+		require.Equal(t, greet, turns[1].User)
+		require.Equal(t, []string{"utter_greet"}, turns[1].Actions())
+		require.Equal(t, []*BotUttered{hi}, turns[1].BotUtterances())
+
+		require.Equal(t, inform, turns[2].User)
+		require.Equal(t, []string{"utter_ask_date"}, turns[2].Actions())
+		require.Equal(t, []*BotUttered{where}, turns[2].BotUtterances())
+		require.Len(t, turns[2].Events, 4)
+	})
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import "reflect"
+
+// Apply appends the events to t.Events, and updates the state of the Tracker
+// accordingly.
+//
+// The state changes mirror those of the DialogueStateTracker in Rasa Open
+// Source, which allows previewing the Tracker that will result from the
+// events returned by an action handler.
+func (t *Tracker) Apply(events ...Event) {
+	for i := range events {
+		t.Events = append(t.Events, events[i])
+		t.apply(events[i])
+
+		if ts := events[i].Time(); !ts.IsZero() {
+			latest := Time(ts)
+			t.LatestEventTime = &latest
+		}
+	}
+}
+
+// ReplayFrom resets the Tracker to its initial state, and rebuilds it by
+// applying the provided events in order.
+//
+// After ReplayFrom returns, t.Events holds a copy of events.
+func (t *Tracker) ReplayFrom(events Events) {
+	t.Events = make(Events, 0, len(events))
+	t.LatestEventTime = nil
+	t.LatestInputChannel = ""
+	t.reset()
+	t.Apply(events...)
+}
+
+// apply updates the state of the Tracker for a single event, without adding
+// the event to t.Events.
+func (t *Tracker) apply(evt Event) {
+	switch e := eventPointer(evt).(type) {
+	case *UserUttered:
+		msg := ParseResult{}
+		if e.ParseData != nil {
+			msg = *e.ParseData
+		}
+		if e.Text != "" {
+			msg.Text = e.Text
+		}
+		t.LatestMessage = &msg
+		t.FollowupAction = ""
+		if e.InputChannel != "" {
+			t.LatestInputChannel = e.InputChannel
+		}
+	case *ActionExecuted:
+		t.setLatestAction(e.ActionName, e.ActionText)
+		t.FollowupAction = ""
+	case *EntitiesAdded:
+		// entities can only be added to the user message which directly
+		// followed `action_listen`.
+		if t.LatestActionName == ActionListen {
+			t.addEntities(e.Entities)
+		}
+	case *SlotSet:
+		if t.Slots == nil {
+			t.Slots = make(Slots)
+		}
+		t.Slots[e.Key] = e.Value
+	case *AllSlotsReset:
+		t.resetSlots()
+	case *Restarted:
+		t.reset()
+		t.FollowupAction = ActionSessionStart
+	case *SessionStarted:
+		t.reset()
+	case *ActiveLoop:
+		t.changeLoopTo(e.Name)
+	case *LoopInterrupted:
+		if t.ActiveLoop != nil {
+			validate := !e.IsInterrupted
+			t.ActiveLoop.Validate = &validate
+		}
+	case *ActionExecutionRejected:
+		if t.ActiveLoop.IsActive() && t.ActiveLoop.Is(e.ActionName) {
+			t.ActiveLoop.Rejected = true
+		}
+	case *ConversationPaused:
+		t.Paused = true
+	case *ConversationResumed:
+		t.Paused = false
+	case *FollowupAction:
+		t.FollowupAction = e.ActionName
+	case *UserUtteranceReverted, *ActionReverted:
+		t.replay()
+	}
+}
+
+// reset resets the Tracker to its initial state. The events are kept.
+func (t *Tracker) reset() {
+	t.resetSlots()
+	t.Paused = false
+	t.LatestAction = nil
+	t.LatestActionName = ""
+	t.LatestMessage = &ParseResult{}
+	t.FollowupAction = ActionListen
+	t.ActiveLoop = nil
+}
+
+// resetSlots sets all slots to their initial value.
+func (t *Tracker) resetSlots() {
+	slots := make(Slots, len(t.Slots))
+	for key := range t.Slots {
+		slots[key] = nil
+	}
+	for key := range t.InitialSlots {
+		slots[key] = t.InitialSlots[key]
+	}
+	t.Slots = slots
+}
+
+// replay resets the Tracker, and re-applies the events that are still in
+// effect after taking reverts into account.
+func (t *Tracker) replay() {
+	applied := appliedEvents(t.Events)
+	t.reset()
+	for i := range applied {
+		t.apply(applied[i])
+	}
+}
+
+// setLatestAction marks the action as the latest executed action. If the
+// action is the active loop, the loop's validation state is reset.
+func (t *Tracker) setLatestAction(action, text string) {
+	t.LatestAction = &LatestAction{ActionName: action, ActionText: text}
+	t.LatestActionName = action
+	if t.ActiveLoop.IsActive() && t.ActiveLoop.Is(action) {
+		validate := true
+		t.ActiveLoop.Validate = &validate
+		t.ActiveLoop.Rejected = false
+	}
+}
+
+// addEntities adds the entities to the latest message, skipping entities
+// which are already present.
+func (t *Tracker) addEntities(entities []Entity) {
+	msg := *t.LatestMessage
+	msg.Entities = append([]Entity{}, msg.Entities...)
+	for i := range entities {
+		exists := false
+		for j := range msg.Entities {
+			if reflect.DeepEqual(entities[i], msg.Entities[j]) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			msg.Entities = append(msg.Entities, entities[i])
+		}
+	}
+	t.LatestMessage = &msg
+}
+
+// changeLoopTo activates the loop with the provided name, or deactivates the
+// active loop if name is empty.
+func (t *Tracker) changeLoopTo(name string) {
+	if name == "" {
+		t.ActiveLoop = nil
+		return
+	}
+
+	validate := true
+	t.ActiveLoop = &TActiveLoop{
+		Name:           name,
+		Validate:       &validate,
+		Rejected:       false,
+		TriggerMessage: t.LatestMessage,
+	}
+}
+
+// appliedEvents returns the events that are still in effect, taking restarts,
+// session starts, reverted user utterances, reverted actions, and repeated
+// loop executions into account.
+func appliedEvents(events Events) (applied Events) {
+	var loops []string
+	for i := range events {
+		if e, ok := eventPointer(events[i]).(*ActiveLoop); ok && e.Name != "" {
+			loops = append(loops, e.Name)
+		}
+	}
+
+	for i := range events {
+		evt := events[i]
+		switch evt.Type() {
+		case EventTypeRestarted, EventTypeSessionStarted:
+			applied = nil
+			continue
+		case EventTypeActionReverted:
+			applied = undoTillPrevious(EventTypeActionExecuted, applied)
+			continue
+		case EventTypeUserUtteranceReverted:
+			// A user message implies there was an `action_listen` right
+			// before it, so both are removed.
+			applied = undoTillPrevious(EventTypeUserUttered, applied)
+			applied = undoTillPrevious(EventTypeActionExecuted, applied)
+			continue
+		case EventTypeActionExecuted:
+			name := eventPointer(evt).(*ActionExecuted).ActionName
+			if sliceContains(loops, name) && !isFirstLoopExecution(name, applied) {
+				applied = undoTillPreviousLoopExecution(name, applied)
+				continue
+			}
+		}
+		applied = append(applied, evt)
+	}
+	return
+}
+
+// undoTillPrevious removes events from the end of the list up to and
+// including the latest event of the provided type.
+func undoTillPrevious(typ EventType, events Events) Events {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type() == typ {
+			return events[:i]
+		}
+	}
+	return events[:0]
+}
+
+// isFirstLoopExecution returns whether the loop is executed for the first
+// time, or whether it is executed within an unhappy path.
+func isFirstLoopExecution(loop string, applied Events) bool {
+	var next string
+	for i := len(applied) - 1; i >= 0; i-- {
+		switch e := eventPointer(applied[i]).(type) {
+		case *ActiveLoop:
+			// a deactivated loop means previous loop events belong to a
+			// different loop.
+			if e.Name == "" {
+				return true
+			}
+		case *ActionExecutionRejected:
+			if e.ActionName == loop {
+				return true
+			}
+		case *UserUttered:
+			if next != "" && next != loop {
+				return true
+			}
+		case *ActionExecuted:
+			if e.ActionName == loop {
+				return false
+			}
+			next = e.ActionName
+		}
+	}
+	return true
+}
+
+// undoTillPreviousLoopExecution removes the actions and user messages that
+// happened after the previous execution of the loop.
+func undoTillPreviousLoopExecution(loop string, applied Events) Events {
+	result := make(Events, 0, len(applied))
+	i := len(applied) - 1
+	for ; i >= 0; i-- {
+		evt := applied[i]
+		if e, ok := eventPointer(evt).(*ActionExecuted); ok && e.ActionName == loop {
+			break
+		}
+		switch evt.Type() {
+		case EventTypeActionExecuted, EventTypeUserUttered, EventTypeUserFeaturization:
+			// dropped
+		default:
+			result = append(result, evt)
+		}
+	}
+
+	// result holds the kept events in reverse order
+	kept := append(Events{}, applied[:i+1]...)
+	for j := len(result) - 1; j >= 0; j-- {
+		kept = append(kept, result[j])
+	}
+	return kept
+}
+
+// sliceContains returns whether the slice contains the value.
+func sliceContains(slice []string, value string) bool {
+	for i := range slice {
+		if slice[i] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrackerReplay
+func TestTrackerReplay(t *testing.T) {
+	greet := &UserUttered{
+		Text:      "hello",
+		ParseData: &ParseResult{Intent: Intent{Name: "greet", Confidence: 1}},
+	}
+	inform := &UserUttered{
+		Text:      "in Paris",
+		ParseData: &ParseResult{Intent: Intent{Name: "inform", Confidence: 1}},
+	}
+
+	t.Run("slots", func(t *testing.T) {
+		var tracker Tracker
+		tracker.InitialSlots = Slots{"count": 1.0}
+		tracker.ReplayFrom(Events{
+			&SlotSet{Key: "city", Value: "Paris"},
+			SlotSet{Key: "count", Value: 2.0}, // value events are supported
+		})
+		require.Equal(t, Slots{"city": "Paris", "count": 2.0}, tracker.Slots)
+		require.Len(t, tracker.Events, 2)
+
+		tracker.Apply(&AllSlotsReset{})
+		require.Equal(t, Slots{"city": nil, "count": 1.0}, tracker.Slots)
+		require.Len(t, tracker.Events, 3)
+	})
+
+	t.Run("latest message and action", func(t *testing.T) {
+		var tracker Tracker
+		tracker.ReplayFrom(Events{
+			&ActionExecuted{ActionName: ActionListen},
+			greet,
+			&ActionExecuted{ActionName: "utter_greet"},
+		})
+		require.Equal(t, "greet", tracker.LatestMessage.Intent.Name)
+		require.Equal(t, "hello", tracker.LatestMessage.Text)
+		require.Equal(t, "utter_greet", tracker.LatestActionName)
+		require.Equal(t, "", tracker.FollowupAction)
+	})
+
+	t.Run("restart and session start", func(t *testing.T) {
+		var tracker Tracker
+		tracker.ReplayFrom(Events{
+			&SlotSet{Key: "city", Value: "Paris"},
+			&ActiveLoop{Name: "form"},
+			&ConversationPaused{},
+			&Restarted{},
+		})
+		require.Equal(t, Slots{"city": nil}, tracker.Slots)
+		require.False(t, tracker.HasActiveLoop())
+		require.False(t, tracker.Paused)
+		require.Equal(t, ActionSessionStart, tracker.FollowupAction)
+
+		tracker.Apply(&SlotSet{Key: "city", Value: "Rome"}, &SessionStarted{})
+		require.Equal(t, Slots{"city": nil}, tracker.Slots)
+		require.Equal(t, ActionListen, tracker.FollowupAction)
+	})
+
+	t.Run("active loop", func(t *testing.T) {
+		var tracker Tracker
+		tracker.ReplayFrom(Events{greet, &ActiveLoop{Name: "form"}})
+		require.True(t, tracker.HasActiveLoop())
+		require.True(t, tracker.ActiveLoop.ShouldValidate())
+		require.Equal(t, "greet", tracker.ActiveLoop.TriggerMessage.Intent.Name)
+
+		tracker.Apply(&LoopInterrupted{IsInterrupted: true})
+		require.False(t, tracker.ActiveLoop.ShouldValidate())
+
+		tracker.Apply(&ActionExecutionRejected{ActionName: "form"})
+		require.True(t, tracker.ActiveLoop.Rejected)
+
+		tracker.Apply(&ActionExecuted{ActionName: "form"})
+		require.True(t, tracker.ActiveLoop.ShouldValidate())
+		require.False(t, tracker.ActiveLoop.Rejected)
+
+		tracker.Apply(&ActiveLoop{})
+		require.False(t, tracker.HasActiveLoop())
+	})
+
+	t.Run("entities added", func(t *testing.T) {
+		city := Entity{Entity: "city", Value: "Paris"}
+
+		var tracker Tracker
+		tracker.ReplayFrom(Events{
+			&ActionExecuted{ActionName: ActionListen},
+			inform,
+			&EntitiesAdded{Entities: []Entity{city, city}},
+		})
+		require.Equal(t, []Entity{city}, tracker.LatestMessage.Entities)
+		require.Empty(t, inform.ParseData.Entities)
+
+		// entities are only added to the message following `action_listen`
+		tracker.Apply(
+			&ActionExecuted{ActionName: "action_search"},
+			&EntitiesAdded{Entities: []Entity{{Entity: "cuisine", Value: "greek"}}},
+		)
+		require.Equal(t, []Entity{city}, tracker.LatestMessage.Entities)
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		var tracker Tracker
+		tracker.Apply(&ConversationPaused{})
+		require.True(t, tracker.Paused)
+		tracker.Apply(&ConversationResumed{})
+		require.False(t, tracker.Paused)
+	})
+
+	t.Run("followup action", func(t *testing.T) {
+		var tracker Tracker
+		tracker.Apply(&FollowupAction{ActionName: "action_next"})
+		require.Equal(t, "action_next", tracker.FollowupAction)
+		tracker.Apply(&ActionExecuted{ActionName: "action_next"})
+		require.Equal(t, "", tracker.FollowupAction)
+	})
+
+	t.Run("user utterance reverted", func(t *testing.T) {
+		var tracker Tracker
+		tracker.ReplayFrom(Events{
+			&ActionExecuted{ActionName: ActionListen},
+			greet,
+			&ActionExecuted{ActionName: "utter_greet"},
+			&ActionExecuted{ActionName: ActionListen},
+			inform,
+			&SlotSet{Key: "city", Value: "Paris"},
+			&UserUtteranceReverted{},
+		})
+		require.Equal(t, Slots{"city": nil}, tracker.Slots)
+		require.Equal(t, "greet", tracker.LatestMessage.Intent.Name)
+		require.Equal(t, "utter_greet", tracker.LatestActionName)
+		require.Len(t, tracker.Events, 7)
+	})
+
+	t.Run("action reverted", func(t *testing.T) {
+		var tracker Tracker
+		tracker.ReplayFrom(Events{
+			&ActionExecuted{ActionName: ActionListen},
+			inform,
+			&ActionExecuted{ActionName: "action_search"},
+			&SlotSet{Key: "city", Value: "Paris"},
+			&ActionReverted{},
+		})
+		require.Equal(t, Slots{"city": nil}, tracker.Slots)
+		require.Equal(t, "inform", tracker.LatestMessage.Intent.Name)
+		require.Equal(t, ActionListen, tracker.LatestActionName)
+	})
+
+	t.Run("repeated loop execution", func(t *testing.T) {
+		events := Events{
+			&ActiveLoop{Name: "form"},
+			&ActionExecuted{ActionName: "form"},
+			&SlotSet{Key: "city", Value: "Paris"},
+			&ActionExecuted{ActionName: "utter_ask"},
+			&ActionExecuted{ActionName: "form"},
+		}
+		applied := appliedEvents(events)
+		require.Equal(t, Events{events[0], events[1], events[2]}, applied)
+	})
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	perrors "github.com/pkg/errors"
+)
+
+// ErrSlotNotSet is the cause of a SlotError returned for slots which are not
+// present in the Tracker, or which hold no value.
+var ErrSlotNotSet = perrors.New("slot is not set")
+
+// SlotError is returned by the typed slot accessors of Tracker when the value
+// of a slot cannot be read as the requested type.
+type SlotError struct {
+	// Slot holds the name of the slot.
+	Slot string
+
+	// Value holds the value of the slot.
+	Value interface{}
+
+	// Target describes the requested type.
+	Target string
+
+	// Cause holds the underlying error.
+	Cause error
+}
+
+// ensure interface
+var _ error = (*SlotError)(nil)
+
+// Error implements builtin.error.
+func (e *SlotError) Error() string {
+	switch e.Cause {
+	case ErrSlotNotSet:
+		return fmt.Sprintf("slot [%s] is not set", e.Slot)
+	case ErrSlotNotDefined:
+		return fmt.Sprintf("slot [%s] is not defined in the domain", e.Slot)
+	}
+	return fmt.Sprintf(
+		"unable to read slot [%s] with value [%v] (%T) as %s: %s",
+		e.Slot,
+		e.Value,
+		e.Value,
+		e.Target,
+		e.Cause.Error(),
+	)
+}
+
+// Unwrap implements errors.Unwrap.
+func (e *SlotError) Unwrap() error {
+	return e.Cause
+}
+
+// SlotString returns the value of the slot as a string.
+func (t *Tracker) SlotString(name string) (val string, err error) {
+	err = t.slotAs(name, "string", func(v interface{}) (err error) {
+		val, err = coerceString(v)
+		return
+	})
+	return
+}
+
+// SlotFloat returns the value of the slot as a float64. Numeric values of any
+// type, as well as strings containing a number, are converted.
+func (t *Tracker) SlotFloat(name string) (val float64, err error) {
+	err = t.slotAs(name, "float64", func(v interface{}) (err error) {
+		val, err = coerceFloat(v)
+		return
+	})
+	return
+}
+
+// SlotInt returns the value of the slot as an int. Floating point values are
+// only converted if they hold a whole number.
+func (t *Tracker) SlotInt(name string) (val int, err error) {
+	err = t.slotAs(name, "int", func(v interface{}) (err error) {
+		val, err = coerceInt(v)
+		return
+	})
+	return
+}
+
+// SlotBool returns the value of the slot as a bool. Numbers and strings are
+// converted as by Rasa: 1 is true and other numbers are false, and the
+// strings "true" and "false" are accepted regardless of case.
+func (t *Tracker) SlotBool(name string) (val bool, err error) {
+	err = t.slotAs(name, "bool", func(v interface{}) (err error) {
+		val, err = coerceBool(v)
+		return
+	})
+	return
+}
+
+// SlotStrings returns the value of the slot as a slice of strings. A single
+// string value is returned as a slice with one element.
+func (t *Tracker) SlotStrings(name string) (val []string, err error) {
+	err = t.slotAs(name, "[]string", func(v interface{}) (err error) {
+		val, err = coerceStrings(v)
+		return
+	})
+	return
+}
+
+// SlotInto decodes the value of the slot into the value pointed to by dst,
+// by marshalling the value to JSON and unmarshalling it into dst.
+//
+// SlotInto can be used to read slots holding structured values into structs,
+// maps, and slices.
+func (t *Tracker) SlotInto(name string, dst interface{}) error {
+	rdst := reflect.ValueOf(dst)
+	if rdst.Kind() != reflect.Ptr || rdst.IsNil() {
+		return perrors.Errorf("invalid destination for slot [%s]: expected a non-nil pointer, got %T", name, dst)
+	}
+
+	return t.slotAs(name, rdst.Elem().Type().String(), func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, dst)
+	})
+}
+
+// slotAs looks up the value of the slot, and passes it to fn for conversion.
+// Errors are wrapped in a SlotError.
+func (t *Tracker) slotAs(name, target string, fn func(v interface{}) error) error {
+	val, ok := t.Slot(name)
+	if !ok || val == nil {
+		return &SlotError{Slot: name, Target: target, Cause: ErrSlotNotSet}
+	}
+	if err := fn(val); err != nil {
+		return &SlotError{Slot: name, Value: val, Target: target, Cause: err}
+	}
+	return nil
+}
+
+// errUnsupportedType is returned by the coerce functions for values that
+// cannot be converted.
+func errUnsupportedType(v interface{}) error {
+	return perrors.Errorf("unsupported type %T", v)
+}
+
+// coerceString converts v to a string.
+func coerceString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	}
+	return "", errUnsupportedType(v)
+}
+
+// coerceFloat converts v to a float64.
+func coerceFloat(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case float32:
+		return float64(val), nil
+	case json.Number:
+		return val.Float64()
+	case string:
+		return strconv.ParseFloat(val, 64)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	}
+	return 0, errUnsupportedType(v)
+}
+
+// bounds of the int type
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// coerceInt converts v to an int.
+func coerceInt(v interface{}) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return int(i), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(val); err == nil {
+			return i, nil
+		}
+	}
+
+	f, err := coerceFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f >= float64(maxInt) || f < float64(minInt) {
+		return 0, perrors.Errorf("%v is not a whole number in the range of int", f)
+	}
+	return int(f), nil
+}
+
+// coerceBool converts v to a bool as Rasa's bool_from_any does.
+func coerceBool(v interface{}) (bool, error) {
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		if isNumeric(val) {
+			f, err := strconv.ParseFloat(val, 64)
+			return f == 1, err
+		}
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return false, perrors.Errorf("%q is not a boolean", val)
+	}
+
+	f, err := coerceFloat(v)
+	if err != nil {
+		return false, err
+	}
+	return f == 1, nil
+}
+
+// isNumeric returns whether s is a non-empty string of digits, such as
+// accepted by Python's str.isnumeric.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// coerceStrings converts v to a slice of strings.
+func coerceStrings(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case []string:
+		return val, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		result := make([]string, 0, len(val))
+		for i := range val {
+			s, err := coerceString(val[i])
+			if err != nil {
+				return nil, perrors.WithMessagef(err, "element %d", i)
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	}
+	return nil, errUnsupportedType(v)
+}
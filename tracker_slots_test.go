@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rasa
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrackerSlots
+func TestTrackerSlots(t *testing.T) {
+	var tracker Tracker
+	err := json.Unmarshal([]byte(`{
+		"sender_id": "test",
+		"events": [],
+		"slots": {
+			"city": "Paris",
+			"count": 3,
+			"price": 12.5,
+			"numeric": "42",
+			"confirmed": true,
+			"flag": "false",
+			"cuisines": ["italian", "greek"],
+			"mixed": ["italian", 1],
+			"address": {"street": "Main Street", "number": 12},
+			"empty": null
+		}
+	}`), &tracker)
+	require.NoError(t, err)
+
+	t.Run("SlotString", func(t *testing.T) {
+		val, err := tracker.SlotString("city")
+		require.NoError(t, err)
+		require.Equal(t, "Paris", val)
+
+		_, err = tracker.SlotString("count")
+		require.Error(t, err)
+	})
+
+	t.Run("SlotFloat", func(t *testing.T) {
+		cases := []struct {
+			slot   string
+			expect float64
+		}{
+			{"count", 3},
+			{"price", 12.5},
+			{"numeric", 42},
+		}
+		for i := range cases {
+			val, err := tracker.SlotFloat(cases[i].slot)
+			require.NoErrorf(t, err, "failed on %d", i)
+			require.Equalf(t, cases[i].expect, val, "failed on %d", i)
+		}
+
+		_, err := tracker.SlotFloat("city")
+		require.Error(t, err)
+	})
+
+	t.Run("SlotInt", func(t *testing.T) {
+		val, err := tracker.SlotInt("count")
+		require.NoError(t, err)
+		require.Equal(t, 3, val)
+
+		val, err = tracker.SlotInt("numeric")
+		require.NoError(t, err)
+		require.Equal(t, 42, val)
+
+		_, err = tracker.SlotInt("price")
+		require.Error(t, err)
+	})
+
+	t.Run("SlotBool", func(t *testing.T) {
+		val, err := tracker.SlotBool("confirmed")
+		require.NoError(t, err)
+		require.True(t, val)
+
+		val, err = tracker.SlotBool("flag")
+		require.NoError(t, err)
+		require.False(t, val)
+
+		// numbers other than 1 are false
+		val, err = tracker.SlotBool("count")
+		require.NoError(t, err)
+		require.False(t, val)
+
+		_, err = tracker.SlotBool("city")
+		require.Error(t, err)
+	})
+
+	t.Run("SlotStrings", func(t *testing.T) {
+		val, err := tracker.SlotStrings("cuisines")
+		require.NoError(t, err)
+		require.Equal(t, []string{"italian", "greek"}, val)
+
+		val, err = tracker.SlotStrings("city")
+		require.NoError(t, err)
+		require.Equal(t, []string{"Paris"}, val)
+
+		_, err = tracker.SlotStrings("mixed")
+		require.Error(t, err)
+	})
+
+	t.Run("SlotInto", func(t *testing.T) {
+		var address struct {
+			Street string `json:"street"`
+			Number int    `json:"number"`
+		}
+		require.NoError(t, tracker.SlotInto("address", &address))
+		require.Equal(t, "Main Street", address.Street)
+		require.Equal(t, 12, address.Number)
+
+		var cuisines []string
+		require.NoError(t, tracker.SlotInto("cuisines", &cuisines))
+		require.Equal(t, []string{"italian", "greek"}, cuisines)
+
+		require.Error(t, tracker.SlotInto("address", address))
+	})
+
+	t.Run("SlotAs", func(t *testing.T) {
+		var city string
+		require.True(t, tracker.SlotAs("city", &city))
+		require.Equal(t, "Paris", city)
+
+		var count int
+		require.False(t, tracker.SlotAs("city", &count))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, slot := range []string{"empty", "missing"} {
+			_, err := tracker.SlotString(slot)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrSlotNotSet))
+		}
+
+		_, err := tracker.SlotFloat("city")
+		var serr *SlotError
+		require.True(t, errors.As(err, &serr))
+		require.Equal(t, "city", serr.Slot)
+		require.Equal(t, "Paris", serr.Value)
+		require.Equal(t, "float64", serr.Target)
+	})
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package transcript renders the conversation of a rasa.Tracker as a
+// human-readable transcript, in Markdown or as a self-contained HTML page.
+//
+// Transcripts show the messages of the user with their intent, confidence
+// and entities, the messages of the bot with their buttons and images, and
+// the executed actions, slot changes, form activity, pauses and restarts.
+package transcript
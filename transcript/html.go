@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transcript
+
+import (
+	"html/template"
+	"io"
+
+	"go.scarlet.dev/rasa"
+)
+
+// htmlTemplate renders a transcript as a self-contained HTML page.
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"time":       func(e Entry, o Options) string { return o.formatTime(e.Time) },
+	"value":      formatValue,
+	"entities":   formatEntities,
+	"confidence": formatConfidence,
+	"title":      elementTitle,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; color: #222; }
+.msg { margin: 1em 0; padding: .6em .9em; border-radius: .6em; max-width: 75%; }
+.user { background: #dceefd; margin-left: auto; }
+.bot { background: #f0f0f0; }
+.meta, .event, time { color: #777; font-size: .85em; }
+.event { margin: .2em 0 .2em 1em; }
+.divider { border-top: 1px solid #ccc; margin: 1.5em 0; text-align: center; color: #777; }
+.buttons span { display: inline-block; border: 1px solid #999; border-radius: 1em; padding: .1em .7em; margin: .2em .2em 0 0; }
+img { max-width: 100%; }
+code { background: #eee; padding: 0 .2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- $opts := .Options}}
+{{- range .Entries}}
+{{- if eq .Kind "user"}}
+<div class="msg user">
+{{- with time . $opts}}<time>{{.}}</time><br>{{end}}
+<div>{{.Text}}</div>
+{{- if .Intent}}
+<div class="meta">intent <code>{{.Intent}}</code> ({{confidence .Confidence}})
+{{- if .Entities}} · entities <code>{{entities .Entities}}</code>{{end}}</div>
+{{- end}}
+</div>
+{{- else if eq .Kind "bot"}}
+<div class="msg bot">
+{{- with time . $opts}}<time>{{.}}</time><br>{{end}}
+{{- if .Text}}
+<div>{{.Text}}</div>
+{{- end}}
+{{- with .Message}}
+{{- if .Image}}
+<img src="{{.Image}}" alt="image">
+{{- end}}
+{{- with .AttachmentURL}}
+<div><a href="{{.}}">attachment</a></div>
+{{- else}}{{with .Attachment}}
+<div class="meta">attachment <code>{{value .}}</code></div>
+{{- end}}{{end}}
+{{- if or .Buttons .QuickReplies}}
+<div class="buttons">
+{{- range .Buttons}}<span title="{{or .URL .Payload}}">{{.Title}}</span>{{end}}
+{{- range .QuickReplies}}<span title="{{.Payload}}">{{.Title}}</span>{{end}}
+</div>
+{{- end}}
+{{- range .Elements}}
+<div class="meta">element <code>{{title .}}</code></div>
+{{- end}}
+{{- if .Custom}}
+<div class="meta">custom <code>{{value .Custom}}</code></div>
+{{- end}}
+{{- end}}
+</div>
+{{- else if or (eq .Kind "restart") (eq .Kind "session")}}
+<div class="divider">{{.Text}}</div>
+{{- else if eq .Kind "action"}}
+<div class="event">action <code>{{.Text}}</code></div>
+{{- else if eq .Kind "slot"}}
+<div class="event">slot <code>{{.Text}}</code> = <code>{{value .Value}}</code></div>
+{{- else if eq .Kind "loop"}}
+<div class="event">{{if .Text}}form <code>{{.Text}}</code> activated{{else}}form deactivated{{end}}</div>
+{{- else}}
+<div class="event"><em>{{.Text}}</em></div>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML writes the transcript of the tracker as a self-contained HTML page,
+// which does not reference any external resources other than the images
+// sent by the bot.
+func HTML(w io.Writer, tracker *rasa.Tracker, opts Options) error {
+	return htmlTemplate.Execute(w, struct {
+		Title   string
+		Options Options
+		Entries []Entry
+	}{
+		Title:   opts.title(tracker),
+		Options: opts,
+		Entries: Entries(tracker.Events, opts),
+	})
+}
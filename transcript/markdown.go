@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transcript
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"go.scarlet.dev/rasa"
+)
+
+// markdownEscaper escapes the characters with a meaning in Markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+)
+
+// Markdown writes the transcript of the tracker as Markdown.
+//
+// Messages are written as quotes headed by their sender, and the other
+// events as lists between the messages.
+func Markdown(w io.Writer, tracker *rasa.Tracker, opts Options) error {
+	var b strings.Builder
+	b.WriteString("# " + markdownEscaper.Replace(opts.title(tracker)) + "\n")
+
+	inList := false
+	for _, entry := range Entries(tracker.Events, opts) {
+		switch entry.Kind {
+		case KindUser, KindBot:
+			inList = false
+			b.WriteByte('\n')
+			writeMarkdownMessage(&b, &entry, &opts)
+		case KindRestart, KindSession:
+			inList = false
+			b.WriteString("\n---\n\n_" + entry.Text + "_\n")
+		default:
+			if !inList {
+				b.WriteByte('\n')
+				inList = true
+			}
+			b.WriteString("- " + markdownEvent(&entry) + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownMessage writes a message of the user or the bot.
+func writeMarkdownMessage(b *strings.Builder, entry *Entry, opts *Options) {
+	if entry.Kind == KindUser {
+		b.WriteString("**User**")
+	} else {
+		b.WriteString("**Bot**")
+	}
+	if ts := opts.formatTime(entry.Time); ts != "" {
+		b.WriteString(" · " + ts)
+	}
+	b.WriteByte('\n')
+
+	var lines []string
+	if entry.Text != "" {
+		lines = append(lines, strings.Split(markdownEscaper.Replace(entry.Text), "\n")...)
+	}
+
+	if entry.Kind == KindUser && entry.Intent != "" {
+		info := "_intent_ `" + entry.Intent + "` (" + formatConfidence(entry.Confidence) + ")"
+		if len(entry.Entities) > 0 {
+			info += " · _entities_ `" + formatEntities(entry.Entities) + "`"
+		}
+		lines = append(lines, "", info)
+	}
+
+	if msg := entry.Message; msg != nil {
+		var rich []string
+		if msg.Image != "" {
+			rich = append(rich, "![image]("+msg.Image+")")
+		}
+		if url := msg.AttachmentURL(); url != "" {
+			rich = append(rich, "[attachment]("+url+")")
+		} else if msg.Attachment != nil {
+			rich = append(rich, "_attachment_ `"+formatValue(msg.Attachment)+"`")
+		}
+		n := 0
+		for _, buttons := range [][]rasa.Button{msg.Buttons, msg.QuickReplies} {
+			for i := range buttons {
+				n++
+				rich = append(rich, strconv.Itoa(n)+". "+markdownButton(&buttons[i]))
+			}
+		}
+		for i := range msg.Elements {
+			rich = append(rich, "- "+markdownEscaper.Replace(elementTitle(msg.Elements[i])))
+		}
+		if len(msg.Custom) > 0 {
+			rich = append(rich, "_custom_ `"+formatValue(msg.Custom)+"`")
+		}
+		if len(rich) > 0 {
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, rich...)
+		}
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString(">\n")
+			continue
+		}
+		b.WriteString("> " + line + "\n")
+	}
+}
+
+// markdownButton formats a button with its payload or URL.
+func markdownButton(button *rasa.Button) string {
+	title := markdownEscaper.Replace(button.Title)
+	if button.URL != "" {
+		return "[" + title + "](" + button.URL + ")"
+	}
+	return title + " `" + button.Payload + "`"
+}
+
+// markdownEvent formats an event other than a message.
+func markdownEvent(entry *Entry) string {
+	switch entry.Kind {
+	case KindAction:
+		return "action `" + entry.Text + "`"
+	case KindSlot:
+		return "slot `" + entry.Text + "` = `" + formatValue(entry.Value) + "`"
+	case KindLoop:
+		if entry.Text == "" {
+			return "form deactivated"
+		}
+		return "form `" + entry.Text + "` activated"
+	}
+	return "_" + entry.Text + "_"
+}
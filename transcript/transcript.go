@@ -0,0 +1,225 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.scarlet.dev/rasa"
+)
+
+// Kind describes the kind of an Entry.
+type Kind string
+
+// Kinds of entries.
+const (
+	KindUser    = Kind("user")
+	KindBot     = Kind("bot")
+	KindAction  = Kind("action")
+	KindSlot    = Kind("slot")
+	KindLoop    = Kind("loop")
+	KindPause   = Kind("pause")
+	KindResume  = Kind("resume")
+	KindRestart = Kind("restart")
+	KindSession = Kind("session")
+	KindRevert  = Kind("revert")
+	KindOther   = Kind("other")
+)
+
+// Entry holds an entry of a transcript, which corresponds to an event of the
+// tracker.
+type Entry struct {
+	// Kind holds the kind of the entry.
+	Kind Kind
+
+	// Time holds the time of the event, which may be zero.
+	Time time.Time
+
+	// Text holds the text of user and bot messages, the name of actions,
+	// slots and loops, and a description for other entries.
+	Text string
+
+	// Intent holds the intent of user messages.
+	Intent string
+
+	// Confidence holds the confidence of the intent of user messages.
+	Confidence float64
+
+	// Entities holds the entities of user messages.
+	Entities []rasa.Entity
+
+	// Message holds the message of the bot, for bot entries.
+	Message *rasa.Message
+
+	// Value holds the value of slots.
+	Value interface{}
+}
+
+// Options holds the options of a transcript.
+type Options struct {
+	// Title holds the title of the transcript. Defaults to the sender id of
+	// the tracker.
+	Title string
+
+	// ShowListen includes the executions of action_listen, which are
+	// implied by the messages of the user and omitted by default.
+	ShowListen bool
+
+	// Location holds the timezone in which times are shown. Defaults to
+	// UTC.
+	Location *time.Location
+}
+
+// title returns the title for the tracker.
+func (o *Options) title(tracker *rasa.Tracker) string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return "Conversation with " + tracker.SenderID
+}
+
+// formatTime formats the time of an entry, or returns an empty string for the
+// zero time.
+func (o *Options) formatTime(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return ""
+	}
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+// Entries converts the events into the entries of a transcript.
+func Entries(events rasa.Events, opts Options) []Entry {
+	entries := make([]Entry, 0, len(events))
+	for i := range events {
+		entry := Entry{Time: events[i].Time(), Kind: KindOther}
+
+		switch e := rasa.EventPointer(events[i]).(type) {
+		case *rasa.UserUttered:
+			entry.Kind = KindUser
+			entry.Text = e.Text
+			if e.ParseData != nil {
+				entry.Intent = e.ParseData.Intent.Name
+				entry.Confidence = e.ParseData.Intent.Confidence
+				entry.Entities = e.ParseData.Entities
+			}
+		case *rasa.BotUttered:
+			entry.Kind = KindBot
+			entry.Text = e.Text
+			entry.Message = botMessage(e)
+		case *rasa.ActionExecuted:
+			if e.ActionName == rasa.ActionListen && !opts.ShowListen {
+				continue
+			}
+			entry.Kind = KindAction
+			entry.Text = e.ActionName
+			if entry.Text == "" {
+				entry.Text = e.ActionText
+			}
+		case *rasa.ActionExecutionRejected:
+			entry.Text = "action " + e.ActionName + " rejected its execution"
+		case *rasa.SlotSet:
+			entry.Kind = KindSlot
+			entry.Text = e.Key
+			entry.Value = e.Value
+		case *rasa.AllSlotsReset:
+			entry.Text = "all slots were reset"
+		case *rasa.ActiveLoop:
+			entry.Kind = KindLoop
+			entry.Text = e.Name
+		case *rasa.LoopInterrupted:
+			if e.IsInterrupted {
+				entry.Text = "the active form was interrupted"
+			} else {
+				entry.Text = "the active form continued"
+			}
+		case *rasa.ConversationPaused:
+			entry.Kind = KindPause
+			entry.Text = "the conversation was paused"
+		case *rasa.ConversationResumed:
+			entry.Kind = KindResume
+			entry.Text = "the conversation was resumed"
+		case *rasa.Restarted:
+			entry.Kind = KindRestart
+			entry.Text = "the conversation was restarted"
+		case *rasa.SessionStarted:
+			entry.Kind = KindSession
+			entry.Text = "a new session started"
+		case *rasa.UserUtteranceReverted:
+			entry.Kind = KindRevert
+			entry.Text = "the latest user message was reverted"
+		case *rasa.ActionReverted:
+			entry.Kind = KindRevert
+			entry.Text = "the latest action was reverted"
+		case *rasa.FollowupAction:
+			entry.Text = "followup action " + e.ActionName
+		default:
+			entry.Text = string(events[i].Type()) + " event"
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// botMessage returns the message sent by the bot, which holds the rich
+// content of the BotUttered event.
+func botMessage(e *rasa.BotUttered) *rasa.Message {
+	msg := new(rasa.Message)
+	if len(e.Data) > 0 {
+		if data, err := json.Marshal(e.Data); err == nil {
+			// invalid rich content is not shown
+			_ = json.Unmarshal(data, msg)
+		}
+	}
+	msg.Text = e.Text
+	return msg
+}
+
+// formatValue formats a value as JSON.
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// formatEntities formats the entities as `name=value` pairs, including their
+// role and group.
+func formatEntities(entities []rasa.Entity) string {
+	parts := make([]string, len(entities))
+	for i := range entities {
+		part := entities[i].Entity
+		if entities[i].Role != "" {
+			part += "[" + entities[i].Role + "]"
+		}
+		if entities[i].Group != "" {
+			part += "#" + entities[i].Group
+		}
+		parts[i] = part + "=" + formatValue(entities[i].Value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// elementTitle returns the title of an element of a carousel.
+func elementTitle(element rasa.JSONMap) string {
+	if title, ok := element["title"].(string); ok {
+		return title
+	}
+	return formatValue(element["title"])
+}
+
+// formatConfidence formats a confidence with two decimals.
+func formatConfidence(confidence float64) string {
+	return fmt.Sprintf("%.2f", confidence)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Eddy <eddy@scarlet.dev>
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transcript
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.scarlet.dev/rasa"
+)
+
+// testTracker returns the tracker of a conversation using most events.
+func testTracker() *rasa.Tracker {
+	at := func(sec int64) rasa.Time { return rasa.Time(time.Unix(1603195200+sec, 0)) }
+	return &rasa.Tracker{
+		SenderID: "default",
+		Events: rasa.Events{
+			&rasa.ActionExecuted{ActionName: rasa.ActionSessionStart, Timestamp: at(0)},
+			&rasa.SessionStarted{Timestamp: at(0)},
+			&rasa.ActionExecuted{ActionName: rasa.ActionListen, Timestamp: at(0)},
+			&rasa.UserUttered{
+				Timestamp: at(5),
+				Text:      "book a table in <Paris>",
+				ParseData: &rasa.ParseResult{
+					Intent:   rasa.Intent{Name: "request_restaurant", Confidence: 0.976},
+					Entities: []rasa.Entity{{Entity: "city", Value: "Paris", Role: "destination"}},
+				},
+			},
+			&rasa.ActionExecuted{ActionName: "restaurant_form", Timestamp: at(6)},
+			&rasa.ActiveLoop{Name: "restaurant_form", Timestamp: at(6)},
+			&rasa.SlotSet{Key: "city", Value: "Paris", Timestamp: at(6)},
+			&rasa.BotUttered{
+				Timestamp: at(6),
+				Text:      "Which cuisine?",
+				Data: rasa.JSONMap{
+					"buttons": []interface{}{
+						map[string]interface{}{"title": "French", "payload": "/inform"},
+					},
+					"image": "https://example.com/food.png",
+					"attachment": map[string]interface{}{
+						"type":    "video",
+						"payload": map[string]interface{}{"src": "https://example.com/food.mp4"},
+					},
+				},
+			},
+			&rasa.ActionExecuted{ActionName: rasa.ActionListen, Timestamp: at(6)},
+			&rasa.UserUttered{Text: "/inform", ParseData: &rasa.ParseResult{Intent: rasa.Intent{Name: "inform", Confidence: 1}}},
+			&rasa.ActiveLoop{},
+			&rasa.ConversationPaused{},
+			&rasa.ConversationResumed{},
+			&rasa.Restarted{},
+			&rasa.BotUttered{Text: "Hi *again*"},
+		},
+	}
+}
+
+// TestEntries
+func TestEntries(t *testing.T) {
+	entries := Entries(testTracker().Events, Options{})
+	require.Len(t, entries, 13)
+	require.Equal(t, KindAction, entries[0].Kind)
+	require.Equal(t, KindSession, entries[1].Kind)
+	require.Equal(t, KindUser, entries[2].Kind)
+	require.Equal(t, "request_restaurant", entries[2].Intent)
+	require.Equal(t, KindBot, entries[6].Kind)
+	require.Equal(t, []rasa.Button{{Title: "French", Payload: "/inform"}}, entries[6].Message.Buttons)
+
+	require.Len(t, Entries(testTracker().Events, Options{ShowListen: true}), 15)
+}
+
+// TestMarkdown
+func TestMarkdown(t *testing.T) {
+	expect, err := ioutil.ReadFile("testdata/transcript.md")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Markdown(&buf, testTracker(), Options{}))
+	require.Equal(t, string(expect), buf.String())
+}
+
+// TestHTML
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HTML(&buf, testTracker(), Options{Title: "Ticket #42"}))
+
+	html := buf.String()
+	require.Contains(t, html, "<title>Ticket #42</title>")
+	require.Contains(t, html, "<div>book a table in &lt;Paris&gt;</div>")
+	require.Contains(t, html, "intent <code>request_restaurant</code> (0.98)")
+	require.Contains(t, html, `<span title="/inform">French</span>`)
+	require.Contains(t, html, `<img src="https://example.com/food.png" alt="image">`)
+	require.Contains(t, html, `<div><a href="https://example.com/food.mp4">attachment</a></div>`)
+	require.Contains(t, html, "<time>2020-10-20 12:00:05</time>")
+	require.Contains(t, html, `<div class="event">form deactivated</div>`)
+	require.Contains(t, html, `<div class="divider">the conversation was restarted</div>`)
+	require.NotContains(t, html, "<script")
+}